@@ -0,0 +1,64 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrBufferLimit is returned when input that must be held in memory
+// exceeds MaxBufferedBytes
+var ErrBufferLimit = errors.New("while: input exceeds MaxBufferedBytes")
+
+// ErrLookbehindLimit is returned when a feature would have to remember
+// more previous lines than LookbehindLines allows
+var ErrLookbehindLimit = errors.New("while: look-behind exceeds LookbehindLines")
+
+// lookbehind checks that remembering n previous lines fits in LookbehindLines
+func (c command) lookbehind(n int) error {
+	if limit := int(c.flags.LookbehindLines); limit > 0 && n > limit {
+		return fmt.Errorf("%w: need %d lines, limit is %d", ErrLookbehindLimit, n, limit)
+	}
+	return nil
+}
+
+// buffer reads all of r into memory, failing with ErrBufferLimit
+// if it is larger than MaxBufferedBytes
+func (c command) buffer(r io.Reader) ([]byte, error) {
+	limit := int64(c.flags.MaxBufferedBytes)
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrBufferLimit
+	}
+	return data, nil
+}
+
+// replayable returns a function yielding the input from its start on every call.
+// Seekable input is rewound in place; anything else is buffered in memory first.
+func (c command) replayable(r io.Reader) (func() (io.Reader, error), error) {
+	if seeker, ok := r.(io.ReadSeeker); ok {
+		start, err := seeker.Seek(0, io.SeekCurrent)
+		if err == nil {
+			return func() (io.Reader, error) {
+				_, err := seeker.Seek(start, io.SeekStart)
+				return seeker, err
+			}, nil
+		}
+	}
+
+	data, err := c.buffer(r)
+	if err != nil {
+		return nil, err
+	}
+	return func() (io.Reader, error) {
+		return bytes.NewReader(data), nil
+	}, nil
+}
@@ -0,0 +1,100 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+func TestRepeat(t *testing.T) {
+	body := func(args ...any) gloo.Command { return echo(args...) }
+	want := "a\nb\na\nb\na\nb\n"
+
+	out, _, err := execute(context.Background(), While(body, Repeat(3)), "a\nb\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != want {
+		t.Errorf("seekable: got %q, want %q", out, want)
+	}
+
+	// Input that cannot seek is buffered instead
+	var stdout bytes.Buffer
+	stdin := io.MultiReader(strings.NewReader("a\nb\n"))
+	if err := While(body, Repeat(3)).Executor()(context.Background(), stdin, &stdout, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != want {
+		t.Errorf("buffered: got %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestRepeatNumbering(t *testing.T) {
+	body := func(lineNum int, line string) gloo.Command { return echo(lineNum, line) }
+
+	for numbering, want := range map[RepeatNumbering]string{
+		ContinueNumbering: "1 a\n2 b\n3 a\n4 b\n",
+		RestartNumbering:  "1 a\n2 b\n1 a\n2 b\n",
+	} {
+		out, _, err := execute(context.Background(), WhileIndexed(body, Repeat(2), numbering), "a\nb\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != want {
+			t.Errorf("%v: got %q, want %q", numbering, out, want)
+		}
+	}
+}
+
+func TestRepeatBufferLimit(t *testing.T) {
+	body := func(args ...any) gloo.Command { return echo(args...) }
+
+	stdin := io.MultiReader(strings.NewReader("a\nb\n"))
+	err := While(body, Repeat(2), MaxBufferedBytes(3)).Executor()(context.Background(), stdin, io.Discard, io.Discard)
+	if !errors.Is(err, ErrBufferLimit) {
+		t.Errorf("got %v, want ErrBufferLimit", err)
+	}
+}
+
+func TestLookbehindLimit(t *testing.T) {
+	body := func(args ...any) gloo.Command { return echo(args...) }
+
+	for name, tt := range map[string]struct {
+		cmd   gloo.Command
+		input string
+	}{
+		"collect": {WhileCollect(quoteLines, LookbehindLines(2)), "a\nb\nc\n"},
+		"reverse": {While(body, Backward, LookbehindLines(2)), "a\nb\nc\n"},
+		"unique":  {While(body, DistinctLines, LookbehindLines(2)), "a\nb\na\nc\n"},
+		"diff":    {WhileDiff(1, diffs, LookbehindLines(2)), "a 1\nb 1\na 2\nc 1\n"},
+	} {
+		_, _, err := execute(context.Background(), tt.cmd, tt.input)
+		if !errors.Is(err, ErrLookbehindLimit) {
+			t.Errorf("%s: got %v, want ErrLookbehindLimit", name, err)
+		}
+
+		// One line fewer stays within the limit
+		within := tt.input[:strings.LastIndex(tt.input[:len(tt.input)-1], "\n")+1]
+		if _, _, err := execute(context.Background(), tt.cmd, within); err != nil {
+			t.Errorf("%s: %v within the limit", name, err)
+		}
+	}
+}
+
+func TestRepeatStartAt(t *testing.T) {
+	body := func(args ...any) gloo.Command { return echo(args...) }
+	start := StartAt(func(line string) bool { return line == "b" })
+
+	out, _, err := execute(context.Background(), While(body, Repeat(2), start), "a\nb\nc\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "b\nc\nb\nc\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
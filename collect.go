@@ -0,0 +1,112 @@
+package command
+
+import (
+	"context"
+	"io"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// CollectBody is a function that receives every input line at once and
+// returns a single Command to execute
+type CollectBody func(lines []string) gloo.Command
+
+type collectCommand struct {
+	command
+	body      CollectBody
+	batchSize int // Lines per call to body, or 0 or less for all of them
+}
+
+// WhileCollect reads all input lines, applying the usual trimming, and calls
+// body once with the complete slice at EOF, for commands that are far more
+// efficient in bulk. A FlushOn marker hands over the lines gathered so far.
+// The lines are held in memory, bounded by MaxBufferedBytes.
+func WhileCollect(body CollectBody, parameters ...any) gloo.Command {
+	inputs := gloo.Initialize[string, flags](parameters...)
+	return collectCommand{
+		command: command{flags: inputs.Flags},
+		body:    body,
+	}
+}
+
+// WhileBatch calls body with every batchSize input lines, applying the usual
+// trimming, and once more with any lines left over at EOF. The context is
+// checked before each batch. A batchSize of zero or less passes all the
+// lines at once, like WhileCollect.
+func WhileBatch(body CollectBody, batchSize int, parameters ...any) gloo.Command {
+	inputs := gloo.Initialize[string, flags](parameters...)
+	return collectCommand{
+		command:   command{flags: inputs.Flags},
+		body:      body,
+		batchSize: batchSize,
+	}
+}
+
+func (c collectCommand) Executor() gloo.CommandExecutor {
+	return func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+		l := c.newLoop(ctx, stdout, stderr)
+		l.collector = &collector{
+			limit:      int(c.flags.MaxBufferedBytes),
+			batchSize:  c.batchSize,
+			lookbehind: c.lookbehind,
+			run: func(lines []string) error {
+				if err := l.ctx.Err(); err != nil {
+					return err
+				}
+				if cmd := c.body(c.batch(lines)); cmd != nil {
+					return l.exec(cmd, l.stdout, 0)
+				}
+				return nil
+			},
+		}
+
+		err := l.passes(stdin)
+		if err == nil && (c.batchSize <= 0 || len(l.collector.lines) > 0) {
+			err = l.collector.flush()
+		}
+		return l.finish(err)
+	}
+}
+
+// batch prepends BatchHeader, if set, to a batch of lines
+func (c command) batch(lines []string) []string {
+	if c.flags.BatchHeader == "" {
+		return lines
+	}
+	return append([]string{string(c.flags.BatchHeader)}, lines...)
+}
+
+// collector holds lines gathered for WhileCollect and WhileBatch
+type collector struct {
+	lines      []string
+	size       int
+	limit      int
+	batchSize  int                        // Lines at which to flush, if positive
+	run        func(lines []string) error // Hands the lines to the body
+	lookbehind func(n int) error
+}
+
+// add appends line, flushing once batchSize lines are held. It fails with
+// ErrBufferLimit once limit bytes are held or ErrLookbehindLimit once more
+// lines are held than LookbehindLines.
+func (c *collector) add(line string) error {
+	c.size += len(line)
+	if c.limit > 0 && c.size > c.limit {
+		return ErrBufferLimit
+	}
+	if err := c.lookbehind(len(c.lines) + 1); err != nil {
+		return err
+	}
+	c.lines = append(c.lines, line)
+	if c.batchSize > 0 && len(c.lines) >= c.batchSize {
+		return c.flush()
+	}
+	return nil
+}
+
+// flush hands the lines gathered so far to the body and starts over
+func (c *collector) flush() error {
+	lines := c.lines
+	c.lines, c.size = nil, 0
+	return c.run(lines)
+}
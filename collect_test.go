@@ -0,0 +1,74 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// quoteLines is a collect body writing the lines it gets as a quoted list
+func quoteLines(lines []string) gloo.Command {
+	return echo(fmt.Sprintf("%q", lines))
+}
+
+func TestWhileCollectFilters(t *testing.T) {
+	var stats Stats
+	out, _, err := execute(context.Background(),
+		WhileCollect(quoteLines, Skip(1), SkipBlank, CommentPrefix("#"), TrimPrefix("> "), DistinctLines, &stats),
+		"header\n> a\n\n# note\nb\n> a\n  \nc\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `["a" "b" "c"]` + "\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+	if stats.Lines != 8 || stats.Skipped != 5 {
+		t.Errorf("read %d, skipped %d; want 8, 5", stats.Lines, stats.Skipped)
+	}
+}
+
+func TestWhileCollectEmpty(t *testing.T) {
+	out, _, err := execute(context.Background(), WhileCollect(quoteLines), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[]\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestBatchHeader(t *testing.T) {
+	var stats Stats
+	out, _, err := execute(context.Background(), WhileBatch(quoteLines, 2, BatchHeader("id,name"), &stats), "1,a\n2,b\n3,c\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `["id,name" "1,a" "2,b"]` + "\n" + `["id,name" "3,c"]` + "\n"
+	if out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+	if stats.Lines != 3 {
+		t.Errorf("read %d lines, want 3 without the header", stats.Lines)
+	}
+}
+
+func TestWhileBatchSize(t *testing.T) {
+	for _, tt := range []struct {
+		size int
+		want string
+	}{
+		{2, `["a" "b"]` + "\n" + `["c"]` + "\n"},
+		{0, `["a" "b" "c"]` + "\n"},
+		{-1, `["a" "b" "c"]` + "\n"},
+	} {
+		out, _, err := execute(context.Background(), WhileBatch(quoteLines, tt.size), "a\nb\nc\n")
+		if err != nil {
+			t.Fatalf("size %d: %v", tt.size, err)
+		}
+		if out != tt.want {
+			t.Errorf("size %d: got %q, want %q", tt.size, out, tt.want)
+		}
+	}
+}
@@ -2,9 +2,17 @@ package command
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
 	"io"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	gloo "github.com/gloo-foo/framework"
 )
@@ -13,10 +21,17 @@ import (
 type Body func(args ...any) gloo.Command
 
 type command struct {
-	body  Body
-	flags flags
+	body    Body
+	handle  handler // Replaces body for variants needing more than the fields
+	until   func(line string) bool
+	cond    func() bool     // Checked before reading each line
+	records bufio.SplitFunc // Replaces the record flags, for WhileRecords
+	flags   flags
 }
 
+// handler produces the command for a line from its number, text and fields
+type handler func(lineNum int, line string, args []any) gloo.Command
+
 func While(body Body, parameters ...any) gloo.Command {
 	inputs := gloo.Initialize[string, flags](parameters...)
 	return command{
@@ -25,54 +40,571 @@ func While(body Body, parameters ...any) gloo.Command {
 	}
 }
 
+// Until runs body for every input line until predicate returns true for one,
+// then stops without error, like the shell's until. The line that satisfied
+// predicate is consumed but not passed to body.
+func Until(predicate func(line string) bool, body Body, parameters ...any) gloo.Command {
+	inputs := gloo.Initialize[string, flags](parameters...)
+	return command{
+		body:  body,
+		until: predicate,
+		flags: inputs.Flags,
+	}
+}
+
+// WhileCond runs body for every input line for as long as cond returns true,
+// like the shell's while. It is checked before each line is scanned, so once
+// it returns false the loop stops without error, leaving the next line
+// uncounted and unprocessed. Under Reverse and ExternalSort, which read all
+// of the input first, it is checked before each line is processed instead.
+func WhileCond(cond func() bool, body Body, parameters ...any) gloo.Command {
+	inputs := gloo.Initialize[string, flags](parameters...)
+	return command{
+		body:  body,
+		cond:  cond,
+		flags: inputs.Flags,
+	}
+}
+
 func (c command) Executor() gloo.CommandExecutor {
 	return func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
-		// While loop that reads from stdin line by line
-		// For each line, parse it according to FieldSeparator and call body function
-		scanner := bufio.NewScanner(stdin)
-
-		for scanner.Scan() {
-			line := scanner.Text()
-
-			// Parse line into fields based on FieldSeparator
-			var args []any
-			if c.flags.FieldSeparator != "" {
-				// Split by field separator
-				fields := strings.Split(line, string(c.flags.FieldSeparator))
-				args = make([]any, len(fields))
-				for i, field := range fields {
-					args[i] = field
-				}
-			} else {
-				// Default: split on whitespace
-				fields := strings.Fields(line)
-				args = make([]any, len(fields))
-				for i, field := range fields {
-					args[i] = field
-				}
-			}
+		l := c.newLoop(ctx, stdout, stderr)
 
-			// Call body function with parsed arguments
-			cmd := c.body(args...)
-			if cmd == nil {
-				// Body returned nil, skip this line
-				continue
-			}
-
-			// Execute the command returned by body
-			err := cmd.Executor()(ctx, strings.NewReader(""), stdout, stderr)
+		if c.flags.Validate {
+			input, err := l.decompress(stdin)
 			if err != nil {
 				return err
 			}
+			return l.validate(input)
+		}
+
+		return l.finish(l.passes(stdin))
+	}
+}
 
-			// Check for context cancellation
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
+// loop holds the state of a single execution
+type loop struct {
+	command
+	ctx            context.Context
+	commandCtx     context.Context // Context commands run with
+	release        func()          // Releases commandCtx under ShutdownGrace
+	stdout, stderr io.Writer
+	sink           io.Writer // stdout as given, before any output flags wrap it
+	lineNum        int
+	stats          Stats
+	pool           *pool
+	rate           rate
+	collector      *collector
+	timingStarted  bool
+	resultsStarted bool
+	lastOutput     []byte
+	firstFields    int // Field count of the first line, plus one
+	checksum       hash.Hash
+	failures       []error // Line failures under ContinueOnError
+	start          time.Time
+	stopReason     StopReason
+	detected       bool
+	sample         []string // Lines held back by AutoDetectSeparator
+	text           string   // Current line as read
+	random         *random
+	capped         *capped // Set with MaxOutputLines
+	spinner        spinner
+	record         []string            // Fields of the current CSV record
+	broken         bool                // A command returned ErrBreak
+	seen           map[string]struct{} // Lines read so far, under Unique
+	previous       string              // Previous line, under UniqueAdjacent
+	hasPrevious    bool
+	reported       int  // Executed count last passed to Progress
+	started        bool // The StartAt line has been seen
+	ending         bool // The StopAt line is the last to be processed
+	calls          int  // Lines handed to the body so far
+}
+
+func (c command) newLoop(ctx context.Context, stdout, stderr io.Writer) *loop {
+	l := &loop{command: c, ctx: ctx, stdout: stdout, stderr: stderr, sink: stdout}
+	l.start = l.now()
+	l.random = newRandom(int64(c.flags.RetrySeed))
+	l.spinner.on = bool(c.flags.Spinner) && isTerminal(stderr)
+	if c.flags.ResourceLimit > 0 {
+		l.ctx = withResources(ctx, int(c.flags.ResourceLimit))
+	}
+	l.commandCtx = l.ctx
+	if grace := time.Duration(c.flags.ShutdownGrace); grace > 0 {
+		l.commandCtx, l.release = graceful(l.ctx, grace)
+	}
+	if len(c.flags.ExtraOutputs) > 0 {
+		l.stdout = c.fanOut(l.stdout)
+	}
+	if c.flags.ChecksumOutput {
+		l.checksum = sha256.New()
+		l.stdout = io.MultiWriter(l.stdout, l.checksum)
+	}
+	if c.flags.MaxOutputLines > 0 {
+		l.capped = &capped{w: l.stdout, remaining: int(c.flags.MaxOutputLines)}
+		l.stdout = l.capped
+	}
+	if c.flags.Parallelism > 1 {
+		l.pool = newPool(l, int(c.flags.Parallelism), false)
+	}
+	if c.flags.AdaptiveParallel > 1 {
+		l.pool = newPool(l, int(c.flags.AdaptiveParallel), true)
+	}
+	if c.flags.TransactionalOrderedParallel > 1 {
+		l.pool = newPool(l, int(c.flags.TransactionalOrderedParallel), false)
+	}
+	if l.pool != nil || c.flags.StallTimeout > 0 {
+		// Commands running in parallel, or the stall watchdog,
+		// share stderr with the loop
+		l.stderr = &locked{w: l.stderr}
+	}
+	return l
+}
+
+// finish waits for any commands still running and publishes the stats
+func (l *loop) finish(err error) error {
+	if l.pool != nil {
+		err = stopped(l.pool.close(err))
+		l.stats.Concurrency = l.pool.limit
+	}
+	if l.release != nil {
+		l.release()
+	}
+	l.clearSpinner()
+	if l.flags.Progress.Func != nil && (l.reported == 0 || l.reported != l.stats.Executed) {
+		l.flags.Progress.Func(l.stats.Executed)
+	}
+	if herr := l.timingHeader(); err == nil {
+		err = herr
+	}
+	if rerr := l.closeResults(); err == nil {
+		err = rerr
+	}
+	l.stats.StopReason = l.reason(err)
+	if l.flags.OnStop != nil {
+		l.flags.OnStop(l.stats.StopReason)
+	}
+	if l.checksum != nil {
+		l.stats.Checksum = hex.EncodeToString(l.checksum.Sum(nil))
+	}
+	if l.flags.Stats != nil {
+		*l.flags.Stats = l.stats
+	}
+	if l.flags.Verbose {
+		fmt.Fprintf(l.stderr, "while: read=%d processed=%d skipped=%d\n",
+			l.stats.Lines, l.stats.Executed, l.stats.Skipped)
+	}
+	if err == nil && len(l.failures) > 0 {
+		err = l.summarize()
+	}
+	if errors.Is(err, context.DeadlineExceeded) && errors.Is(l.ctx.Err(), context.DeadlineExceeded) {
+		err = &deadlineError{lines: l.stats.Executed, err: err}
+	}
+	return err
+}
+
+// passes runs the loop over input once, or Repeat times,
+// until the input ends or a limit stops it
+func (l *loop) passes(input io.Reader) error {
+	return stopped(l.repeat(input))
+}
+
+// repeat runs the loop over input once, or Repeat times
+func (l *loop) repeat(input io.Reader) error {
+	input, err := l.decompress(input)
+	if err != nil {
+		return err
+	}
+
+	if l.flags.Repeat <= 1 {
+		return l.run(input)
+	}
+
+	// Repeating needs to read the input more than once
+	rewind, err := l.replayable(input)
+	if err != nil {
+		return err
+	}
+	for pass := 0; pass < int(l.flags.Repeat); pass++ {
+		input, err := rewind()
+		if err != nil {
+			return err
+		}
+		if l.flags.RepeatNumbering == RestartNumbering {
+			l.lineNum = 0
+		}
+		// Every pass skips ahead to the StartAt line again
+		l.started = false
+		if err := l.run(input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run reads input line by line, calling the body for each line
+func (l *loop) run(input io.Reader) error {
+	if l.flags.ExternalSort {
+		return l.runSorted(input)
+	}
+	if l.flags.CSV {
+		return l.runCSV(input)
+	}
+	if l.flags.Reverse {
+		return l.runReversed(input)
+	}
+
+	err := l.readRecords(input, l.ready, func(n int, text string) error {
+		if l.skip(n) {
+			return nil
+		}
+		return l.next(text)
+	})
+	if err != nil {
+		return err
+	}
+	return l.endSample()
+}
+
+// ready stops the loop before the next record is read once the context is
+// done or the WhileCond condition no longer holds. While the separator is
+// still being detected the condition is checked as the sample is processed.
+func (l *loop) ready() error {
+	if err := l.ctx.Err(); err != nil {
+		return err
+	}
+	if l.detecting() {
+		return nil
+	}
+	return l.check()
+}
+
+// skip reports whether the record at position n of the input is one of the
+// first Skip records, counting it as read and skipped
+func (l *loop) skip(n int) bool {
+	if n > int(l.flags.Skip) {
+		return false
+	}
+	l.discard()
+	return true
+}
+
+// discard counts a line dropped as it was read as read and skipped
+func (l *loop) discard() {
+	l.lineNum++
+	l.stats.Lines++
+	l.stats.Skipped++
+}
+
+// line prepares a single input line and processes it
+func (l *loop) line(text string) error {
+	if err := l.limits(); err != nil {
+		return err
+	}
+	if l.flags.FlushOn != "" && text == string(l.flags.FlushOn) {
+		return l.flush()
+	}
+
+	l.lineNum++
+	l.stats.Lines++
+	l.text = text
+	if err := l.reportRate(); err != nil {
+		return err
+	}
+	if err := l.spin(text); err != nil {
+		return err
+	}
+	line := text
+	if l.records == nil {
+		// Raw records from WhileRecords reach the body as they were read
+		var (
+			skip bool
+			err  error
+		)
+		if line, skip, err = l.filter(text); skip {
+			return err
+		}
+	}
+	if dup, err := l.duplicate(line); err != nil || dup {
+		l.stats.Skipped++
+		return err
+	}
+
+	if l.collector != nil {
+		return l.collector.add(line)
+	}
+	return l.process(line)
+}
+
+// filter applies the flags that read or alter the text of a line:
+// UTF8Policy, TrimPrefix and TrimSuffix, StartAt, SkipEmpty, CommentPrefix,
+// MinLineLen and MaxLineLenFilter, and StopAt. It returns the line to
+// process, or reports that it is skipped.
+func (l *loop) filter(text string) (line string, skip bool, err error) {
+	switch l.flags.UTF8Policy {
+	case UTF8Replace:
+		text = strings.ToValidUTF8(text, "\uFFFD")
+	case UTF8Reject:
+		if !utf8.ValidString(text) {
+			return text, true, l.fail(l.lineError(l.lineNum, text, ErrInvalidUTF8), text)
+		}
+	}
+	line = l.trim(text)
+	if l.before(line) || l.ignore(line) {
+		l.stats.Skipped++
+		return line, true, nil
+	}
+	if stop, err := l.after(line); stop {
+		return line, true, err
+	}
+	return line, false, nil
+}
+
+// process calls the body function for a line and executes the command it returns
+func (l *loop) process(line string) error {
+	if l.until != nil && l.until(line) {
+		return l.stop(StopUntil)
+	}
+
+	fields := l.record
+	if fields == nil {
+		fields = l.split(line)
+	}
+	if err := l.checkFieldCount(len(fields)); err != nil {
+		return err
+	}
+
+	if w := l.flags.Tee.Writer; w != nil {
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+
+	// Call body function with parsed arguments
+	args := l.args(fields)
+	cmd := l.call(line, args)
+	if cmd == nil && l.flags.Fallback != nil {
+		cmd = l.flags.Fallback(args...)
+	}
+	if l.flags.DryRun {
+		return l.preview(line, cmd)
+	}
+	if cmd == nil {
+		if l.flags.StrictNoNil {
+			return l.fail(l.lineError(l.lineNum, line, ErrNilCommand), l.text)
+		}
+		// Body returned nil, skip this line
+		l.stats.Skipped++
+		if l.flags.WarnOnSkip {
+			_, err := fmt.Fprintf(l.stderr, "while: line %d: skipped: %q\n", l.lineNum, truncate(line, maxErrorText))
+			return err
+		}
+		return nil
+	}
+	l.stats.Executed++
+	l.progress()
+
+	cmd = l.pipe(cmd, line)
+	if l.flags.ErrorFallback != nil {
+		cmd = orElse{primary: cmd, fallback: func() gloo.Command {
+			return l.pipe(l.flags.ErrorFallback(args...), line)
+		}}
+	}
+
+	// Execute the command returned by body
+	r := result{lineNum: l.lineNum, line: line, text: l.text}
+	if l.pool != nil {
+		return l.pool.dispatch(r, cmd)
+	}
+	if err := l.execute(cmd, r); err != nil {
+		return err
+	}
+
+	// Check for context cancellation
+	select {
+	case <-l.ctx.Done():
+		return l.ctx.Err()
+	default:
+		return nil
+	}
+}
+
+// call invokes the handler or body for the current line
+func (l *loop) call(line string, args []any) gloo.Command {
+	if l.handle != nil {
+		return l.handle(l.lineNum, line, args)
+	}
+	l.calls++
+	return l.bodyFor(l.calls)(args...)
+}
+
+// bodyFor returns the body that handles the call-th line to reach a body
+func (l *loop) bodyFor(call int) Body {
+	if n := len(l.flags.AlternateBodies); n > 0 {
+		return l.flags.AlternateBodies[(call-1)%n]
+	}
+	return l.body
+}
+
+// result is the outcome of the command run for a single line
+type result struct {
+	lineNum int
+	line    string // Line after trimming
+	text    string // Line as read
+	output  []byte // Captured output, if any
+	elapsed time.Duration
+	err     error
+}
+
+// execute runs cmd, passing its output through any configured output hooks
+func (l *loop) execute(cmd gloo.Command, r result) error {
+	if !l.captures() {
+		r.elapsed, r.err = l.timed(cmd, l.stdout, r.lineNum)
+		return l.settle(r)
+	}
+
+	// Capture the output so it can be inspected before being written
+	var output bytes.Buffer
+	r.elapsed, r.err = l.timed(cmd, &output, r.lineNum)
+	r.output = output.Bytes()
+	return l.emit(r)
+}
+
+// captures reports whether command output must be buffered per line
+func (l *loop) captures() bool {
+	return l.flags.OnOutput != nil || bool(l.flags.OnlyChanged) || bool(l.flags.UniqueOutput) ||
+		l.flags.Retries > 0 || l.flags.Commit != nil
+}
+
+// timed runs cmd for a line, reporting how long it took
+func (l *loop) timed(cmd gloo.Command, stdout io.Writer, lineNum int) (time.Duration, error) {
+	defer l.watch(lineNum)()
+	start := l.now()
+	err := l.retry(cmd, stdout, lineNum)
+	return l.now().Sub(start), err
+}
+
+// exec runs cmd for a line writing its output to stdout, under LineTimeout
+// if set. The line number, unless zero, is stored in the command's context.
+func (l *loop) exec(cmd gloo.Command, stdout io.Writer, lineNum int) error {
+	if sem := l.flags.SharedSemaphore.Semaphore; sem != nil {
+		if err := sem.Acquire(l.ctx, 1); err != nil {
+			return err
+		}
+		defer sem.Release(1)
+	}
+	ctx := l.commandCtx
+	if lineNum > 0 {
+		ctx = context.WithValue(ctx, LineNumberKey{}, lineNum)
+	}
+	if timeout := time.Duration(l.flags.LineTimeout); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	err := cmd.Executor()(ctx, strings.NewReader(""), stdout, l.stderr)
+	if err == nil && l.ctx.Err() == nil {
+		// A command ignoring its context still fails once past LineTimeout
+		err = ctx.Err()
+	}
+	return err
+}
+
+// emit writes the captured output of the command for a line, then settles it
+func (l *loop) emit(r result) error {
+	if l.broken {
+		return nil
+	}
+	if bool(l.flags.OnlyChanged) && unchanged(r.line, r.output) {
+		r.output = nil
+	}
+	if l.flags.UniqueOutput && len(r.output) > 0 {
+		if bytes.Equal(r.output, l.lastOutput) {
+			l.stats.Suppressed++
+			r.output = nil
+		} else {
+			l.lastOutput = append(l.lastOutput[:0], r.output...)
+		}
+	}
+
+	if l.flags.OnOutput != nil {
+		var meta any
+		if l.flags.Meta != nil {
+			meta = l.flags.Meta(r.line)
+		}
+		l.flags.OnOutput(meta, r.output)
+	}
+
+	if l.flags.Commit != nil {
+		if r.err == nil {
+			if err := l.flags.Commit(r.lineNum, r.output); err != nil {
+				return l.lineError(r.lineNum, r.line, err)
 			}
 		}
+	} else if _, err := l.stdout.Write(r.output); err != nil {
+		return err
+	}
+	return l.settle(r)
+}
+
+// unchanged reports whether output is exactly line as a single line of text
+func unchanged(line string, output []byte) bool {
+	text, ok := bytes.CutSuffix(output, []byte("\n"))
+	return ok && string(text) == line
+}
 
-		return scanner.Err()
+// settle records a finished line and reports the error
+// its command returned, attributed to the line
+func (l *loop) settle(r result) error {
+	broke := errors.Is(r.err, ErrBreak)
+	if control(r.err) {
+		r.err = nil
+	}
+	if err := l.recordTiming(r); err != nil {
+		return err
+	}
+	if err := l.recordResult(r); err != nil {
+		return err
+	}
+	if r.err != nil {
+		return l.fail(l.lineError(r.lineNum, r.line, r.err), r.text)
+	}
+	if broke {
+		l.broken = true
+		return l.stop(StopBreak)
+	}
+	return nil
+}
+
+// trim strips the first matching TrimPrefix and TrimSuffix from line
+func (c command) trim(line string) string {
+	for _, prefix := range c.flags.TrimPrefix {
+		if trimmed, ok := strings.CutPrefix(line, string(prefix)); ok {
+			line = trimmed
+			break
+		}
+	}
+	for _, suffix := range c.flags.TrimSuffix {
+		if trimmed, ok := strings.CutSuffix(line, string(suffix)); ok {
+			line = trimmed
+			break
+		}
+	}
+	return line
+}
+
+// ignore reports whether line is skipped without calling the body
+func (c command) ignore(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if c.flags.SkipEmpty && trimmed == "" {
+		return true
+	}
+	if c.flags.CommentPrefix != "" && strings.HasPrefix(trimmed, string(c.flags.CommentPrefix)) {
+		return true
+	}
+	n := len(line)
+	if c.flags.LineLenRunes {
+		n = utf8.RuneCountInString(line)
 	}
+	return n < int(c.flags.MinLineLen) || c.flags.MaxLineLenFilter > 0 && n > int(c.flags.MaxLineLenFilter)
 }
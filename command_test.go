@@ -0,0 +1,289 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// echo returns a command writing args to stdout like fmt.Println
+func echo(args ...any) gloo.Command {
+	return gloo.RawCommand(func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+		_, err := fmt.Fprintln(stdout, args...)
+		return err
+	})
+}
+
+// fails returns a command failing with err
+func fails(err error) gloo.Command {
+	return gloo.RawCommand(func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+		return err
+	})
+}
+
+// execute runs cmd over input, returning what it wrote to stdout and stderr
+func execute(ctx context.Context, cmd gloo.Command, input string) (stdout, stderr string, err error) {
+	var out, errs bytes.Buffer
+	err = cmd.Executor()(ctx, strings.NewReader(input), &out, &errs)
+	return out.String(), errs.String(), err
+}
+
+func TestSkip(t *testing.T) {
+	body := func(args ...any) gloo.Command { return echo(args...) }
+
+	for name, tt := range map[string]struct {
+		params []any
+		want   string
+	}{
+		"lines":  {nil, "b\na\n"},
+		"repeat": {[]any{Repeat(2)}, "b\na\nb\na\n"},
+		"sorted": {[]any{SortedOrder}, "a\nb\n"},
+		"csv":    {[]any{CSVInput}, "b\na\n"},
+	} {
+		out, _, err := execute(context.Background(), While(body, append(tt.params, Skip(1))...), "h\nb\na\n")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if out != tt.want {
+			t.Errorf("%s: got %q, want %q", name, out, tt.want)
+		}
+	}
+}
+
+func TestSkipCountsTowardLineNumbers(t *testing.T) {
+	body := func(lineNum int, line string) gloo.Command { return echo(lineNum, line) }
+
+	var stats Stats
+	out, _, err := execute(context.Background(), WhileIndexed(body, Skip(2), &stats), "h1\nh2\na\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "3 a\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+	if stats.Lines != 3 || stats.Skipped != 2 {
+		t.Errorf("got %d lines, %d skipped; want 3, 2", stats.Lines, stats.Skipped)
+	}
+}
+
+func TestLineLength(t *testing.T) {
+	body := func(args ...any) gloo.Command { return echo(args...) }
+	input := "ab\nabc\nabcd\nabcde\néé\néée\n"
+
+	for name, tt := range map[string]struct {
+		params []any
+		want   string
+	}{
+		"bytes":     {[]any{MinLineLen(3), MaxLineLenFilter(4)}, "abc\nabcd\néé\n"},
+		"runes":     {[]any{MinLineLen(3), MaxLineLenFilter(4), RuneLength}, "abc\nabcd\néée\n"},
+		"min only":  {[]any{MinLineLen(5)}, "abcde\néée\n"},
+		"max only":  {[]any{MaxLineLenFilter(2), RuneLength}, "ab\néé\n"},
+		"exact":     {[]any{MinLineLen(4), MaxLineLenFilter(4)}, "abcd\néé\n"},
+		"unlimited": {[]any{MinLineLen(0), MaxLineLenFilter(0)}, input},
+		"trimmed":   {[]any{MinLineLen(4), TrimPrefix("a")}, "bcde\néé\néée\n"},
+	} {
+		var stats Stats
+		out, _, err := execute(context.Background(), While(body, append(tt.params, &stats)...), input)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if out != tt.want {
+			t.Errorf("%s: got %q, want %q", name, out, tt.want)
+		}
+		if kept := strings.Count(out, "\n"); stats.Skipped != 6-kept {
+			t.Errorf("%s: %d skipped, want %d", name, stats.Skipped, 6-kept)
+		}
+	}
+}
+
+func TestTrim(t *testing.T) {
+	body := func(args ...any) gloo.Command { return echo(fmt.Sprintf("%q", args)) }
+
+	for name, tt := range map[string]struct {
+		params []any
+		want   string
+	}{
+		"none":          {nil, `[">" "a" "b;"]` + "\n" + `["c"]` + "\n"},
+		"prefix":        {[]any{TrimPrefix("> ")}, `["a" "b;"]` + "\n" + `["c"]` + "\n"},
+		"suffix":        {[]any{TrimSuffix(";")}, `[">" "a" "b"]` + "\n" + `["c"]` + "\n"},
+		"both":          {[]any{TrimPrefix("> "), TrimSuffix(";")}, `["a" "b"]` + "\n" + `["c"]` + "\n"},
+		"first matches": {[]any{TrimPrefix(">"), TrimPrefix("> ")}, `["a" "b;"]` + "\n" + `["c"]` + "\n"},
+	} {
+		out, _, err := execute(context.Background(), While(body, tt.params...), "> a b;\nc\n")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if out != tt.want {
+			t.Errorf("%s: got %q, want %q", name, out, tt.want)
+		}
+	}
+}
+
+func TestStrictNoNil(t *testing.T) {
+	body := func(args ...any) gloo.Command {
+		if args[0] == "-" {
+			return nil
+		}
+		return echo(args...)
+	}
+
+	out, _, err := execute(context.Background(), While(body), "a\n-\nb\n-\n")
+	if err != nil || out != "a\nb\n" {
+		t.Errorf("SkipNil: got %q, %v; want both lines, no error", out, err)
+	}
+
+	out, _, err = execute(context.Background(), While(body, RejectNil), "a\n-\nb\n-\n")
+	var lineErr *LineError
+	if !errors.Is(err, ErrNilCommand) || !errors.As(err, &lineErr) || lineErr.Line != 2 {
+		t.Fatalf("RejectNil: got %v, want ErrNilCommand at line 2", err)
+	}
+	if out != "a\n" {
+		t.Errorf("RejectNil: got %q, want only the line before the first nil", out)
+	}
+}
+
+func TestMetaReachesOnOutput(t *testing.T) {
+	type tag struct{ line string }
+	body := func(args ...any) gloo.Command { return echo(args[1], args[0]) }
+
+	var got []string
+	meta := Meta(func(line string) any { return &tag{line: line} })
+	onOutput := OnOutput(func(meta any, output []byte) {
+		got = append(got, meta.(*tag).line+" => "+string(output))
+	})
+
+	for _, n := range []int{1, 4} {
+		got = nil
+		out, _, err := execute(context.Background(), While(body, meta, onOutput, Parallelism(n)), "a 1\nb 2\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != "1 a\n2 b\n" {
+			t.Errorf("Parallelism(%d): got output %q", n, out)
+		}
+		if want := "a 1 => 1 a\n,b 2 => 2 b\n"; strings.Join(got, ",") != want {
+			t.Errorf("Parallelism(%d): OnOutput got %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestOnlyChanged(t *testing.T) {
+	body := func(args ...any) gloo.Command {
+		switch args[0] {
+		case "upper":
+			return echo("UPPER")
+		case "twice":
+			return gloo.RawCommand(func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+				_, err := io.WriteString(stdout, "twice\ntwice\n")
+				return err
+			})
+		}
+		return echo(args...)
+	}
+
+	out, _, err := execute(context.Background(), While(body, ChangedOutput), "same\nupper\ntwice\nsame\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "UPPER\ntwice\ntwice\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestUniqueOutput(t *testing.T) {
+	body := func(args ...any) gloo.Command { return echo(args...) }
+
+	var stats Stats
+	out, _, err := execute(context.Background(), While(body, DistinctOutput, &stats), "A\nA\nB\nA\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "A\nB\nA\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+	if stats.Suppressed != 1 {
+		t.Errorf("suppressed %d, want 1", stats.Suppressed)
+	}
+}
+
+func TestAlternateBodies(t *testing.T) {
+	header := func(args ...any) gloo.Command { return echo("header", args[0]) }
+	sequence := func(args ...any) gloo.Command { return echo("sequence", args[0]) }
+	want := "header >one\nsequence ACGT\nheader >two\nsequence TTGA\n"
+
+	for name, tt := range map[string]struct {
+		input  string
+		params []any
+	}{
+		"plain":      {">one\nACGT\n>two\nTTGA\n", nil},
+		"skip":       {"# file\n>one\nACGT\n>two\nTTGA\n", []any{Skip(1)}},
+		"blank line": {">one\nACGT\n\n>two\nTTGA\n", []any{SkipBlank}},
+	} {
+		params := append(tt.params, AlternateBodies{header, sequence})
+		out, _, err := execute(context.Background(), While(nil, params...), tt.input)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if out != want {
+			t.Errorf("%s: got %q, want %q", name, out, want)
+		}
+	}
+}
+
+func TestUTF8Policy(t *testing.T) {
+	body := func(args ...any) gloo.Command { return echo(args...) }
+	input := "ok\nbad\xff\n"
+
+	for name, tt := range map[string]struct {
+		policy UTF8Policy
+		want   string
+		err    error
+	}{
+		"passthrough": {UTF8Passthrough, "ok\nbad\xff\n", nil},
+		"replace":     {UTF8Replace, "ok\nbad�\n", nil},
+		"reject":      {UTF8Reject, "ok\n", ErrInvalidUTF8},
+	} {
+		out, _, err := execute(context.Background(), While(body, tt.policy, KeepGoing), input)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: got %v, want %v", name, err, tt.err)
+		}
+		if out != tt.want {
+			t.Errorf("%s: got %q, want %q", name, out, tt.want)
+		}
+	}
+}
+
+func TestUnique(t *testing.T) {
+	body := func(args ...any) gloo.Command { return echo(args...) }
+	input := "a\na\nb\na\n\n\nb\n"
+
+	for name, tt := range map[string]struct {
+		params  []any
+		want    string
+		skipped int
+	}{
+		"all":      {nil, "a\na\nb\na\nb\n", 2},
+		"distinct": {[]any{DistinctLines}, "a\nb\n", 5},
+		"adjacent": {[]any{CollapseRepeats}, "a\nb\na\nb\n", 3},
+		"both":     {[]any{DistinctLines, CollapseRepeats}, "a\nb\n", 5},
+	} {
+		// Blank lines are dropped before they count as the previous line
+		var stats Stats
+		out, _, err := execute(context.Background(), While(body, append(tt.params, SkipBlank, &stats)...), input)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if out != tt.want {
+			t.Errorf("%s: got %q, want %q", name, out, tt.want)
+		}
+		if stats.Skipped != tt.skipped {
+			t.Errorf("%s: skipped %d, want %d", name, stats.Skipped, tt.skipped)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package command
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// ConsumeBody is called by WhileConsume with the first input line and
+// a reader for the rest of the input
+type ConsumeBody func(firstLine string, rest io.Reader) gloo.Command
+
+type consumeCommand struct {
+	command
+	body ConsumeBody
+}
+
+// WhileConsume reads the first input line and calls body with it and the
+// unread rest of the input, which the command also gets as its stdin, for
+// reading a header and handing the remainder to a nested parser. The loop
+// ends once the command returns. Empty input does not call body.
+func WhileConsume(body ConsumeBody, parameters ...any) gloo.Command {
+	inputs := gloo.Initialize[string, flags](parameters...)
+	return consumeCommand{
+		command: command{flags: inputs.Flags},
+		body:    body,
+	}
+}
+
+func (c consumeCommand) Executor() gloo.CommandExecutor {
+	return func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+		l := c.newLoop(ctx, stdout, stderr)
+		return l.finish(c.run(l, stdin))
+	}
+}
+
+// run hands the first line and the rest of input to the body
+func (c consumeCommand) run(l *loop, input io.Reader) error {
+	input, err := l.decompress(input)
+	if err != nil {
+		return err
+	}
+
+	rest := bufio.NewReader(input)
+	first, err := rest.ReadString('\n')
+	if errors.Is(err, io.EOF) && first == "" {
+		return nil
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return readError(1, err)
+	}
+
+	first = strings.TrimSuffix(strings.TrimSuffix(first, "\n"), "\r")
+	l.lineNum++
+	l.stats.Lines++
+
+	line := c.trim(first)
+	cmd := c.body(line, rest)
+	if cmd == nil {
+		l.stats.Skipped++
+		return nil
+	}
+	l.stats.Executed++
+	l.progress()
+
+	if err := cmd.Executor()(l.ctx, rest, l.stdout, l.stderr); err != nil {
+		return l.lineError(l.lineNum, line, err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package command
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// runCSV reads input as CSV records, processing each as a line whose
+// fields are the record's fields, so quoted fields may hold separators
+// and newlines
+func (l *loop) runCSV(input io.Reader) error {
+	reader := csv.NewReader(input)
+	reader.FieldsPerRecord = -1
+	if sep := string(l.flags.FieldSeparator); sep != "" {
+		reader.Comma, _ = utf8.DecodeRuneInString(sep)
+	}
+	defer func() { l.record = nil }()
+
+	for n := 1; ; n++ {
+		if err := l.check(); err != nil {
+			return err
+		}
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if l.skip(n) {
+			continue
+		}
+
+		l.normalize(record)
+		l.record = record
+		if err := l.line(strings.Join(record, string(reader.Comma))); err != nil {
+			return err
+		}
+	}
+}
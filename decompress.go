@@ -0,0 +1,25 @@
+package command
+
+import (
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+)
+
+// Gzip is a Decompressor for gzip compressed input
+func Gzip(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
+
+// Bzip2 is a Decompressor for bzip2 compressed input
+func Bzip2(r io.Reader) (io.Reader, error) {
+	return bzip2.NewReader(r), nil
+}
+
+// decompress wraps input with the configured Decompressor, if any
+func (c command) decompress(input io.Reader) (io.Reader, error) {
+	if c.flags.Decompressor == nil {
+		return input, nil
+	}
+	return c.flags.Decompressor(input)
+}
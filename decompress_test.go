@@ -0,0 +1,47 @@
+package command
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"testing"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// gzipped compresses text with gzip
+func gzipped(t *testing.T, text string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(text)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestGzipRoundTrip(t *testing.T) {
+	input := gzipped(t, "a 1\nb 2\nc 3\n")
+	body := func(args ...any) gloo.Command { return echo(args[1], args[0]) }
+
+	out, _, err := execute(context.Background(), While(body, Decompressor(Gzip)), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "1 a\n2 b\n3 c\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestValidateDecompresses(t *testing.T) {
+	input := gzipped(t, "a 1\nb 2\nc 3\n")
+	body := func(args ...any) gloo.Command { return nil }
+
+	_, stderr, err := execute(context.Background(), While(body, Decompressor(Gzip), ValidateOnly, ExpectFields(2)), input)
+	if err != nil {
+		t.Fatalf("got %v, stderr %q", err, stderr)
+	}
+}
@@ -0,0 +1,94 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// detectSample is how many lines AutoDetectSeparator inspects
+const detectSample = 10
+
+// detectCandidates are the separators AutoDetectSeparator chooses between,
+// falling back to whitespace
+var detectCandidates = []FieldSeparator{"\t", ","}
+
+// detecting reports whether the separator still has to be detected
+func (l *loop) detecting() bool {
+	return bool(l.flags.AutoDetectSeparator) && l.flags.FieldSeparator == "" && l.flags.FieldRegexp.Regexp == nil && !l.detected
+}
+
+// next processes a line, first holding back a sample of lines while the
+// separator is detected
+func (l *loop) next(text string) error {
+	if !l.detecting() {
+		return l.line(text)
+	}
+	l.sample = append(l.sample, text)
+	if len(l.sample) < detectSample {
+		return nil
+	}
+	return l.detect()
+}
+
+// endSample detects the separator from a sample cut short by the end of
+// input, then processes it
+func (l *loop) endSample() error {
+	if !l.detecting() {
+		return nil
+	}
+	return l.detect()
+}
+
+// detect chooses the field separator from the sample of the first lines,
+// then processes them
+func (l *loop) detect() error {
+	sample := l.sample
+	l.sample = nil
+	separator, ambiguous := detectSeparator(sample)
+	if ambiguous {
+		if _, err := fmt.Fprintln(l.stderr, "while: could not detect field separator, splitting on whitespace"); err != nil {
+			return err
+		}
+	}
+	l.flags.FieldSeparator = separator
+	l.stats.Separator = string(separator)
+	l.detected = true
+
+	for _, line := range sample {
+		if err := l.check(); err != nil {
+			return err
+		}
+		if err := l.line(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// detectSeparator picks the candidate occurring the same number of times on
+// every sampled line, preferring the most frequent. It returns "" for
+// whitespace, reporting whether that was a fallback despite candidates occurring.
+func detectSeparator(sample []string) (separator FieldSeparator, ambiguous bool) {
+	best, seen := 0, false
+	for _, candidate := range detectCandidates {
+		count, consistent := -1, true
+		for _, line := range sample {
+			n := strings.Count(line, string(candidate))
+			seen = seen || n > 0
+			if count >= 0 && n != count {
+				consistent = false
+			}
+			count = n
+		}
+
+		switch {
+		case !consistent || count <= 0:
+		case count > best:
+			separator, best = candidate, count
+		case count == best:
+			// Two candidates fit equally well
+			separator = ""
+		}
+	}
+	return separator, separator == "" && seen
+}
@@ -0,0 +1,57 @@
+package command
+
+import (
+	"context"
+	"testing"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+func TestDetectSeparator(t *testing.T) {
+	for name, tt := range map[string]struct {
+		input     string
+		want      string
+		separator string
+		warning   string
+	}{
+		"tsv":        {"a b\tc\nd e\tf\n", "[\"a b\" \"c\"]\n[\"d e\" \"f\"]\n", "\t", ""},
+		"csv":        {"a b,c\nd e,f\n", "[\"a b\" \"c\"]\n[\"d e\" \"f\"]\n", ",", ""},
+		"whitespace": {"a b\nc d\n", "[\"a\" \"b\"]\n[\"c\" \"d\"]\n", "", ""},
+		"ambiguous":  {"a,b\tc\nd,e\tf\n", "[\"a,b\" \"c\"]\n[\"d,e\" \"f\"]\n", "", "while: could not detect field separator, splitting on whitespace\n"},
+	} {
+		var stats Stats
+		out, stderr, err := execute(context.Background(), While(quoteFields, DetectSeparator, &stats), tt.input)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if out != tt.want {
+			t.Errorf("%s: got %q, want %q", name, out, tt.want)
+		}
+		if stats.Separator != tt.separator {
+			t.Errorf("%s: detected %q, want %q", name, stats.Separator, tt.separator)
+		}
+		if stderr != tt.warning {
+			t.Errorf("%s: stderr %q, want %q", name, stderr, tt.warning)
+		}
+	}
+}
+
+func TestDetectSeparatorBuffered(t *testing.T) {
+	for name, tt := range map[string]struct {
+		cmd  gloo.Command
+		want string
+	}{
+		"backward": {While(quoteFields, DetectSeparator, Backward), "[\"a b\" \"c\"]\n[\"d e\" \"f\"]\n"},
+		"sorted":   {While(quoteFields, DetectSeparator, SortedOrder), "[\"a b\" \"c\"]\n[\"d e\" \"f\"]\n"},
+		"spilled":  {While(quoteFields, DetectSeparator, SortedOrder, MaxBufferedBytes(1), TempDir(t.TempDir())), "[\"a b\" \"c\"]\n[\"d e\" \"f\"]\n"},
+		"source":   {WhileSource(slice("a b,c", "d e,f"), quoteFields, DetectSeparator), "[\"a b\" \"c\"]\n[\"d e\" \"f\"]\n"},
+	} {
+		out, _, err := execute(context.Background(), tt.cmd, "d e,f\na b,c\n")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if out != tt.want {
+			t.Errorf("%s: got %q, want %q", name, out, tt.want)
+		}
+	}
+}
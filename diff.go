@@ -0,0 +1,74 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// ErrTooManyKeys is reported by WhileDiff when more distinct keys
+// are seen than DiffMaxKeys allows
+var ErrTooManyKeys = errors.New("too many distinct keys")
+
+// DiffBody is called by WhileDiff when the line for key changes
+type DiffBody func(key, old, new string) gloo.Command
+
+type diffCommand struct {
+	command
+	keyField int
+	body     DiffBody
+}
+
+// WhileDiff tracks the last line seen for each value of the keyField field
+// (1-based) and calls body only when a key's line differs from the previous
+// one, passing both. The first line for a key is a change from "".
+//
+// Memory grows with the number of distinct keys; DiffMaxKeys caps it.
+func WhileDiff(keyField int, body DiffBody, parameters ...any) gloo.Command {
+	inputs := gloo.Initialize[string, flags](parameters...)
+	return diffCommand{
+		command:  command{flags: inputs.Flags},
+		keyField: keyField,
+		body:     body,
+	}
+}
+
+func (c diffCommand) Executor() gloo.CommandExecutor {
+	return func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+		last := make(map[string]string)
+
+		cmd := c.command
+		cmd.handle = func(lineNum int, line string, args []any) gloo.Command {
+			var key string
+			if c.keyField >= 1 && c.keyField <= len(args) {
+				key = fmt.Sprint(args[c.keyField-1])
+			}
+
+			old, seen := last[key]
+			if seen && old == line {
+				return nil
+			}
+			if !seen {
+				if c.flags.DiffMaxKeys > 0 && len(last) >= int(c.flags.DiffMaxKeys) {
+					return failed(ErrTooManyKeys)
+				}
+				if err := c.lookbehind(len(last) + 1); err != nil {
+					return failed(err)
+				}
+			}
+			last[key] = line
+			return c.body(key, old, line)
+		}
+		return cmd.Executor()(ctx, stdin, stdout, stderr)
+	}
+}
+
+// failed is a command that fails with err
+func failed(err error) gloo.Command {
+	return gloo.RawCommand(func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+		return err
+	})
+}
@@ -0,0 +1,39 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// diffs is a DiffBody writing each change it is called with
+func diffs(key, old, new string) gloo.Command {
+	return echo(key+":", "["+old+"]", "->", "["+new+"]")
+}
+
+func TestWhileDiff(t *testing.T) {
+	input := "a 1\nb 1\na 1\na 2\nb 1\nb 3\n"
+	out, _, err := execute(context.Background(), WhileDiff(1, diffs), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "a: [] -> [a 1]\n" +
+		"b: [] -> [b 1]\n" +
+		"a: [a 1] -> [a 2]\n" +
+		"b: [b 1] -> [b 3]\n"
+	if out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestDiffMaxKeys(t *testing.T) {
+	out, _, err := execute(context.Background(), WhileDiff(1, diffs, DiffMaxKeys(2)), "a 1\nb 1\na 2\nc 1\n")
+	if !errors.Is(err, ErrTooManyKeys) {
+		t.Fatalf("got %v, want ErrTooManyKeys", err)
+	}
+	if want := "a: [] -> [a 1]\nb: [] -> [b 1]\na: [a 1] -> [a 2]\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
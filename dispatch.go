@@ -0,0 +1,43 @@
+package command
+
+import (
+	"fmt"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// Handler produces the command for a dispatched line from its remaining fields
+type Handler func(args []string) gloo.Command
+
+// DefaultHandler produces the command for a line whose first field has no Handler
+type DefaultHandler func(name string, args []string) gloo.Command
+
+// Dispatch returns a Body that treats the first field of each line as a
+// command name and calls the matching handler with the remaining fields,
+// for building small command interpreters:
+//
+//	While(Dispatch(map[string]Handler{"ADD": add, "DEL": del}, nil))
+//
+// Lines with an unknown name go to otherwise, or are skipped if it is nil.
+// Empty lines are skipped.
+func Dispatch(handlers map[string]Handler, otherwise DefaultHandler) Body {
+	return func(fields ...any) gloo.Command {
+		if len(fields) == 0 {
+			return nil
+		}
+
+		name := fmt.Sprint(fields[0])
+		args := make([]string, len(fields)-1)
+		for i, field := range fields[1:] {
+			args[i] = fmt.Sprint(field)
+		}
+
+		if handler, ok := handlers[name]; ok {
+			return handler(args)
+		}
+		if otherwise != nil {
+			return otherwise(name, args)
+		}
+		return nil
+	}
+}
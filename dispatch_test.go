@@ -0,0 +1,34 @@
+package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+func TestDispatch(t *testing.T) {
+	handlers := map[string]Handler{
+		"ADD": func(args []string) gloo.Command { return echo("added", strings.Join(args, "+")) },
+		"DEL": func(args []string) gloo.Command { return echo("deleted", len(args)) },
+	}
+	otherwise := func(name string, args []string) gloo.Command { return echo("unknown", name) }
+	input := "ADD a b\nDEL x\nPING\n\nADD\n"
+
+	for name, tt := range map[string]struct {
+		otherwise DefaultHandler
+		want      string
+	}{
+		"default":    {otherwise, "added a+b\ndeleted 1\nunknown PING\nadded \n"},
+		"no default": {nil, "added a+b\ndeleted 1\nadded \n"},
+	} {
+		out, _, err := execute(context.Background(), While(Dispatch(handlers, tt.otherwise)), input)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if out != tt.want {
+			t.Errorf("%s: got %q, want %q", name, out, tt.want)
+		}
+	}
+}
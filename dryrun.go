@@ -0,0 +1,30 @@
+package command
+
+import (
+	"fmt"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// preview reports the command a line would run under DryRun instead of
+// running it, counting it as executed or skipped as usual
+func (l *loop) preview(line string, cmd gloo.Command) error {
+	what := "skipped"
+	if cmd == nil {
+		l.stats.Skipped++
+	} else {
+		l.stats.Executed++
+		l.progress()
+		what = describe(cmd)
+	}
+	_, err := fmt.Fprintf(l.stderr, "while: line %d: %q: %s\n", l.lineNum, line, what)
+	return err
+}
+
+// describe represents cmd by its String method, or by its type
+func describe(cmd gloo.Command) string {
+	if s, ok := cmd.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", cmd)
+}
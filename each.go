@@ -0,0 +1,24 @@
+package command
+
+import (
+	"context"
+	"io"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// WhileEach calls fn for every input line purely for its side effects,
+// without building a command. fn runs as the line's command, so its context
+// honors LineTimeout, and its errors are retried under Retries and reported
+// as line failures subject to ContinueOnError.
+func WhileEach(fn func(ctx context.Context, line string) error, parameters ...any) gloo.Command {
+	inputs := gloo.Initialize[string, flags](parameters...)
+	return command{
+		handle: func(lineNum int, line string, args []any) gloo.Command {
+			return gloo.RawCommand(func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+				return fn(ctx, line)
+			})
+		},
+		flags: inputs.Flags,
+	}
+}
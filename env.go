@@ -0,0 +1,19 @@
+package command
+
+import gloo "github.com/gloo-foo/framework"
+
+// EnvBody is called by WhileEnv with each line and the shared environment
+type EnvBody func(line string, env map[string]string) gloo.Command
+
+// WhileEnv runs body for every input line like While, passing the line and
+// env, for shared settings read by every call. The map is shared, not
+// copied, so body must not modify it while commands run in parallel.
+func WhileEnv(env map[string]string, body EnvBody, parameters ...any) gloo.Command {
+	inputs := gloo.Initialize[string, flags](parameters...)
+	return command{
+		handle: func(lineNum int, line string, args []any) gloo.Command {
+			return body(line, env)
+		},
+		flags: inputs.Flags,
+	}
+}
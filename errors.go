@@ -0,0 +1,161 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Exit codes returned by ExitCode
+const (
+	ExitSuccess   = 0 // Every line was processed successfully
+	ExitLineError = 1 // One or more lines failed
+	ExitFailure   = 2 // Input could not be read or the loop could not run
+)
+
+// ErrNilCommand is reported under StrictNoNil when the body returns no command
+var ErrNilCommand = errors.New("body returned no command")
+
+// maxErrorText is how much of a line IncludeLineInError embeds
+const maxErrorText = 80
+
+// ErrInvalidUTF8 is reported under UTF8Reject for lines that are not valid UTF-8
+var ErrInvalidUTF8 = errors.New("invalid UTF-8")
+
+// ErrBreak, returned by a line's command, stops the loop without error,
+// like the shell's break. Output of later lines still running in parallel
+// is discarded.
+var ErrBreak = errors.New("while: break")
+
+// ErrContinue, returned by a line's command, ends that line without error,
+// like the shell's continue. It is not retried and does not fall back.
+var ErrContinue = errors.New("while: continue")
+
+// control reports whether err is ErrBreak or ErrContinue
+func control(err error) bool {
+	return errors.Is(err, ErrBreak) || errors.Is(err, ErrContinue)
+}
+
+// LineError reports the failure of the command run for a single input line
+type LineError struct {
+	Line int    // 1-based line number
+	Text string // Start of the line, under IncludeLineInError
+	Err  error  // Error returned by the command
+}
+
+func (e *LineError) Error() string {
+	if e.Text != "" {
+		return fmt.Sprintf("while: line %d %q: %v", e.Line, e.Text, e.Err)
+	}
+	return fmt.Sprintf("while: line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode maps an error returned by While to a conventional process exit code.
+// Errors joined together map to the most severe code among them.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitSuccess
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		code := ExitSuccess
+		for _, err := range joined.Unwrap() {
+			code = max(code, ExitCode(err))
+		}
+		return code
+	}
+
+	var lineErr *LineError
+	if errors.As(err, &lineErr) {
+		return ExitLineError
+	}
+	return ExitFailure
+}
+
+// deadlineError reports how far the loop got before the context deadline passed
+type deadlineError struct {
+	lines int
+	err   error
+}
+
+func (e *deadlineError) Error() string {
+	return fmt.Sprintf("while: deadline exceeded after %d lines", e.lines)
+}
+
+func (e *deadlineError) Unwrap() error {
+	return e.err
+}
+
+// lineError attributes err to a line, embedding a truncated
+// copy of the line under IncludeLineInError
+func (l *loop) lineError(lineNum int, line string, err error) *LineError {
+	lineErr := &LineError{Line: lineNum, Err: err}
+	if l.flags.IncludeLineInError {
+		lineErr.Text = truncate(line, maxErrorText)
+	}
+	return lineErr
+}
+
+// truncate shortens s to at most n bytes, marking the cut with "..."
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n-3], "") + "..."
+}
+
+// fail hands a failed line to OnError if set. Otherwise the line stops the
+// loop unless ContinueOnError is set, and lines that fail without stopping
+// it are reported to stderr and written to DeadLetterWriter as they were read.
+func (l *loop) fail(err *LineError, text string) error {
+	if l.flags.OnError != nil {
+		return l.flags.OnError(err.Line, text, err.Err)
+	}
+	if !l.flags.ContinueOnError {
+		return err
+	}
+	l.failures = append(l.failures, err)
+	if _, werr := fmt.Fprintln(l.stderr, err); werr != nil {
+		return werr
+	}
+
+	if w := l.flags.DeadLetterWriter.Writer; w != nil {
+		if _, werr := io.WriteString(w, text+"\n"); werr != nil {
+			return werr
+		}
+	}
+	return nil
+}
+
+// summarize combines the failures recorded under ContinueOnError
+// into a single error according to ErrorSummaryMode
+func (l *loop) summarize() error {
+	switch l.flags.ErrorSummaryMode {
+	case FirstError:
+		return l.failures[0]
+	case LastError:
+		return l.failures[len(l.failures)-1]
+	case CountErrors:
+		return &countError{errs: l.failures}
+	default:
+		return errors.Join(l.failures...)
+	}
+}
+
+// countError reports only how many lines failed
+type countError struct {
+	errs []error
+}
+
+func (e *countError) Error() string {
+	return fmt.Sprintf("while: %d lines failed", len(e.errs))
+}
+
+func (e *countError) Unwrap() []error {
+	return e.errs
+}
@@ -0,0 +1,174 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+func TestDeadlineReportsProgress(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// The second line outlasts the deadline, then finishes without error
+	body := func(args ...any) gloo.Command {
+		return gloo.RawCommand(func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+			if args[0] == "2" {
+				<-ctx.Done()
+			}
+			return nil
+		})
+	}
+
+	_, _, err := execute(ctx, While(body), "1\n2\n3\n4\n")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+	if want := "while: deadline exceeded after 2 lines"; err.Error() != want {
+		t.Errorf("got %q, want %q", err, want)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	lineErr := &LineError{Line: 1, Err: errors.New("failed")}
+	readErr := errors.New("read failed")
+
+	for name, tt := range map[string]struct {
+		err  error
+		want int
+	}{
+		"success":           {nil, ExitSuccess},
+		"line":              {lineErr, ExitLineError},
+		"wrapped line":      {fmt.Errorf("run: %w", lineErr), ExitLineError},
+		"failure":           {readErr, ExitFailure},
+		"joined lines":      {errors.Join(lineErr, &LineError{Line: 2, Err: readErr}), ExitLineError},
+		"joined with other": {errors.Join(lineErr, readErr), ExitFailure},
+		"count":             {&countError{errs: []error{lineErr}}, ExitLineError},
+	} {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("%s: got %d, want %d", name, got, tt.want)
+		}
+	}
+}
+
+func TestExitCodeFromLoop(t *testing.T) {
+	body := func(args ...any) gloo.Command {
+		if args[0] == "bad" {
+			return fails(errors.New("failed"))
+		}
+		return echo(args...)
+	}
+
+	_, _, err := execute(context.Background(), While(body, KeepGoing), "a\nbad\nb\n")
+	if got := ExitCode(err); got != ExitLineError {
+		t.Errorf("got %d for %v, want %d", got, err, ExitLineError)
+	}
+}
+
+func TestErrorSummaryMode(t *testing.T) {
+	body := func(args ...any) gloo.Command {
+		if args[0] != "ok" {
+			return fails(errors.New(args[0].(string)))
+		}
+		return echo(args...)
+	}
+	input := "ok\nfirst\nok\nlast\n"
+
+	for mode, want := range map[ErrorSummaryMode]string{
+		AllErrors:   "while: line 2: first\nwhile: line 4: last",
+		FirstError:  "while: line 2: first",
+		LastError:   "while: line 4: last",
+		CountErrors: "while: 2 lines failed",
+	} {
+		out, stderr, err := execute(context.Background(), While(body, KeepGoing, mode), input)
+		if err == nil || err.Error() != want {
+			t.Errorf("mode %d: got %v, want %q", mode, err, want)
+		}
+		if out != "ok\nok\n" {
+			t.Errorf("mode %d: got output %q", mode, out)
+		}
+		if want := "while: line 2: first\nwhile: line 4: last\n"; stderr != want {
+			t.Errorf("mode %d: got stderr %q, want %q", mode, stderr, want)
+		}
+
+		var lineErr *LineError
+		if !errors.As(err, &lineErr) {
+			t.Errorf("mode %d: %v does not unwrap to a LineError", mode, err)
+		}
+	}
+}
+
+func TestIncludeLineInError(t *testing.T) {
+	body := func(args ...any) gloo.Command { return fails(errors.New("failed")) }
+	long := strings.Repeat("x", 100)
+
+	for name, tt := range map[string]struct {
+		input  string
+		params []any
+		want   string
+	}{
+		"omitted":   {"short line\n", nil, `while: line 1: failed`},
+		"short":     {"short line\n", []any{QuoteLineInError}, `while: line 1 "short line": failed`},
+		"truncated": {long + "\n", []any{QuoteLineInError}, `while: line 1 "` + long[:77] + `...": failed`},
+	} {
+		_, _, err := execute(context.Background(), While(body, tt.params...), tt.input)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%s: got %v, want %q", name, err, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	for _, tt := range []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"abc", 3, "abc"},
+		{"abcd", 3, "..."},
+		{"abcdefgh", 6, "abc..."},
+		// The cut falls inside é, which is dropped rather than split
+		{"abé123", 6, "ab..."},
+		{"日本語", 6, "日..."},
+	} {
+		got := truncate(tt.s, tt.n)
+		if got != tt.want || !utf8.ValidString(got) {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestDeadLetterWriter(t *testing.T) {
+	body := func(args ...any) gloo.Command {
+		if strings.HasPrefix(fmt.Sprint(args[0]), "bad") {
+			return fails(errors.New("failed"))
+		}
+		return echo(args...)
+	}
+
+	for name, params := range map[string][]any{
+		"sequential": nil,
+		"parallel":   {Parallelism(3)},
+	} {
+		var dead bytes.Buffer
+		params = append(params, KeepGoing, TrimPrefix("> "), DeadLetterWriter{&dead})
+		out, _, err := execute(context.Background(), While(body, params...), "> ok1\n> bad1 x\n> ok2\n> bad2\n")
+		if err == nil {
+			t.Fatalf("%s: got nil error, want the failures", name)
+		}
+		if want := "ok1\nok2\n"; out != want {
+			t.Errorf("%s: got %q, want %q", name, out, want)
+		}
+		if want := "> bad1 x\n> bad2\n"; dead.String() != want {
+			t.Errorf("%s: dead letters %q, want %q", name, dead.String(), want)
+		}
+	}
+}
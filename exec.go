@@ -0,0 +1,33 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// Exec returns a Body that runs an external process for every line, passing
+// the line's fields as arguments after args, like xargs. The process reads
+// the command's stdin and writes to its stdout and stderr.
+//
+// The process is killed if the context is cancelled. A non-zero exit status
+// is returned as an *exec.ExitError, whose ExitCode method reports it.
+func Exec(name string, args ...string) Body {
+	return func(fields ...any) gloo.Command {
+		argv := append([]string(nil), args...)
+		for _, field := range fields {
+			argv = append(argv, fmt.Sprint(field))
+		}
+
+		return gloo.RawCommand(func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+			cmd := exec.CommandContext(ctx, name, argv...)
+			cmd.Stdin = stdin
+			cmd.Stdout = stdout
+			cmd.Stderr = stderr
+			return cmd.Run()
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+// lookPath skips the test unless name is installed
+func lookPath(t *testing.T, name string) {
+	t.Helper()
+	if _, err := exec.LookPath(name); err != nil {
+		t.Skipf("%s not found", name)
+	}
+}
+
+func TestExec(t *testing.T) {
+	lookPath(t, "echo")
+
+	out, _, err := execute(context.Background(), While(Exec("echo", "-n", "got:")), "a b\nc\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "got: a bgot: c"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestExecExitStatus(t *testing.T) {
+	lookPath(t, "sh")
+
+	_, _, err := execute(context.Background(), While(Exec("sh", "-c", "exit $0")), "0\n3\n")
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Fatalf("got %v, want exit status 3", err)
+	}
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) || lineErr.Line != 2 {
+		t.Errorf("got %v, want a failure of line 2", err)
+	}
+}
+
+func TestExecStdin(t *testing.T) {
+	lookPath(t, "sh")
+
+	out, _, err := execute(context.Background(), While(Exec("sh", "-c", "cat"), LineInput), "a\nb\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a\nb\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
@@ -0,0 +1,30 @@
+package command
+
+import (
+	"context"
+	"io"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// orElse runs the command from fallback when primary fails,
+// so the line only fails if the fallback does too
+type orElse struct {
+	primary  gloo.Command
+	fallback func() gloo.Command
+}
+
+func (c orElse) Executor() gloo.CommandExecutor {
+	return func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+		err := c.primary.Executor()(ctx, stdin, stdout, stderr)
+		if err == nil || control(err) {
+			return err
+		}
+
+		fallback := c.fallback()
+		if fallback == nil {
+			return err
+		}
+		return fallback.Executor()(ctx, stdin, stdout, stderr)
+	}
+}
@@ -0,0 +1,88 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+func TestFallback(t *testing.T) {
+	body := func(args ...any) gloo.Command {
+		if strings.HasPrefix(args[0].(string), "#") {
+			return nil
+		}
+		return echo("body", args[0])
+	}
+
+	calls := 0
+	fallback := Fallback(func(args ...any) gloo.Command {
+		calls++
+		if args[0] == "#skip" {
+			return nil
+		}
+		return echo("fallback", args[0])
+	})
+
+	var stats Stats
+	out, _, err := execute(context.Background(), While(body, fallback, &stats), "a\n#b\n#skip\nc\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "body a\nfallback #b\nbody c\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+	if calls != 2 {
+		t.Errorf("fallback called %d times, want 2", calls)
+	}
+	if stats.Executed != 3 || stats.Skipped != 1 {
+		t.Errorf("executed %d, skipped %d; want 3, 1", stats.Executed, stats.Skipped)
+	}
+}
+
+func TestFallbackUnderStrictNoNil(t *testing.T) {
+	body := func(args ...any) gloo.Command { return nil }
+	fallback := Fallback(func(args ...any) gloo.Command { return nil })
+
+	_, _, err := execute(context.Background(), While(body, fallback, RejectNil), "a\n")
+	if !errors.Is(err, ErrNilCommand) {
+		t.Errorf("got %v, want ErrNilCommand", err)
+	}
+}
+
+func TestErrorFallback(t *testing.T) {
+	body := func(args ...any) gloo.Command {
+		if strings.HasPrefix(args[0].(string), "bad") {
+			return fails(errors.New("failed"))
+		}
+		return echo("body", args[0])
+	}
+
+	calls := 0
+	fallback := ErrorFallback(func(args ...any) gloo.Command {
+		calls++
+		if args[0] == "bad-twice" {
+			return fails(errors.New("fallback failed"))
+		}
+		return echo("fallback", args[0])
+	})
+
+	out, _, err := execute(context.Background(), While(body, fallback), "a\nbad\nb\n")
+	if err != nil {
+		t.Fatalf("got %v, want the failure cleared", err)
+	}
+	if want := "body a\nfallback bad\nbody b\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+	if calls != 1 {
+		t.Errorf("fallback called %d times, want 1", calls)
+	}
+
+	_, _, err = execute(context.Background(), While(body, fallback), "bad-twice\n")
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) || lineErr.Err.Error() != "fallback failed" {
+		t.Errorf("got %v, want the fallback's failure", err)
+	}
+}
@@ -0,0 +1,134 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// fields parses line into body arguments according to FieldSeparator
+func (c command) fields(line string) []any {
+	return c.args(c.split(line))
+}
+
+// split breaks line into fields according to FieldRegexp or FieldSeparator
+func (c command) split(line string) []string {
+	n := int(c.flags.MaxFields)
+	if n <= 0 {
+		n = -1
+	}
+
+	var fields []string
+	if re := c.flags.FieldRegexp.Regexp; re != nil {
+		// Split at every match of the pattern
+		fields = re.Split(line, n)
+	} else if c.flags.FieldSeparator != "" {
+		// Split by field separator
+		fields = strings.SplitN(line, string(c.flags.FieldSeparator), n)
+	} else {
+		// Default: split on whitespace
+		fields = fieldsN(line, n)
+	}
+
+	c.normalize(fields)
+	return fields
+}
+
+// fieldsN splits line around runs of whitespace into at most n fields, the
+// last holding the rest of the line from its first non-space character.
+// A negative n means no limit.
+func fieldsN(line string, n int) []string {
+	if n < 0 {
+		return strings.Fields(line)
+	}
+
+	var fields []string
+	rest := strings.TrimLeftFunc(line, unicode.IsSpace)
+	for rest != "" && len(fields) < n-1 {
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end < 0 {
+			break
+		}
+		fields = append(fields, rest[:end])
+		rest = strings.TrimLeftFunc(rest[end:], unicode.IsSpace)
+	}
+	if rest != "" {
+		fields = append(fields, rest)
+	}
+	return fields
+}
+
+// args converts split fields into body arguments, applying SelectFields
+func (c command) args(fields []string) []any {
+	if c.flags.SelectFields != nil {
+		fields = c.selectFields(fields)
+	}
+
+	args := make([]any, len(fields))
+	for i, field := range fields {
+		args[i] = field
+	}
+	return args
+}
+
+// strs converts body arguments back into field strings
+func strs(args []any) []string {
+	fields := make([]string, len(args))
+	for i, arg := range args {
+		fields[i] = fmt.Sprint(arg)
+	}
+	return fields
+}
+
+// selectFields picks the SelectFields columns out of fields
+func (c command) selectFields(fields []string) []string {
+	selected := make([]string, len(c.flags.SelectFields))
+	for i, index := range c.flags.SelectFields {
+		if index >= 1 && index <= len(fields) {
+			selected[i] = fields[index-1]
+		}
+	}
+	return selected
+}
+
+// normalize applies TrimColumns and StripQuotesColumns to fields in place
+func (c command) normalize(fields []string) {
+	for _, index := range c.flags.TrimColumns {
+		if index >= 1 && index <= len(fields) {
+			fields[index-1] = strings.TrimSpace(fields[index-1])
+		}
+	}
+	for _, index := range c.flags.StripQuotesColumns {
+		if index >= 1 && index <= len(fields) {
+			fields[index-1] = unquote(fields[index-1])
+		}
+	}
+}
+
+// unquote removes one pair of matching single or double quotes around field
+func unquote(field string) string {
+	if len(field) >= 2 {
+		first, last := field[0], field[len(field)-1]
+		if first == last && (first == '"' || first == '\'') {
+			return field[1 : len(field)-1]
+		}
+	}
+	return field
+}
+
+// checkFieldCount warns on stderr under WarnFieldCountChange when a line
+// has a different number of fields than the first line
+func (l *loop) checkFieldCount(n int) error {
+	if !l.flags.WarnFieldCountChange {
+		return nil
+	}
+	if l.firstFields == 0 {
+		l.firstFields = n + 1
+		return nil
+	}
+	if first := l.firstFields - 1; n != first {
+		_, err := fmt.Fprintf(l.stderr, "while: line %d: %d fields, first line had %d\n", l.lineNum, n, first)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// quoteFields is a body writing the fields it gets as a quoted list
+func quoteFields(args ...any) gloo.Command {
+	return echo(fmt.Sprintf("%q", args))
+}
+
+func TestFieldRegexp(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, line, want string
+	}{
+		{`[,;]`, "a,;b", `["a" "" "b"]`},
+		{`[,;]+`, "a,;b", `["a" "b"]`},
+		{`\s*,\s*`, "a , b,c", `["a" "b" "c"]`},
+		{`^#\s*`, "# a b", `["" "a b"]`},
+		{`\s*;$`, "a b ;", `["a b" ""]`},
+		{`^x`, "axb", `["axb"]`},
+	} {
+		re := FieldRegexp{regexp.MustCompile(tt.pattern)}
+		out, _, err := execute(context.Background(), While(quoteFields, re), tt.line+"\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := tt.want + "\n"; out != want {
+			t.Errorf("%s on %q: got %q, want %q", tt.pattern, tt.line, out, want)
+		}
+	}
+}
+
+func TestFieldRegexpOverridesSeparator(t *testing.T) {
+	re := FieldRegexp{regexp.MustCompile(`;`)}
+	out, _, err := execute(context.Background(), While(quoteFields, re, FieldSeparator(",")), "a,b;c\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `["a,b" "c"]` + "\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestFieldSeparatorKeepsEmptyFields(t *testing.T) {
+	for _, tt := range []struct {
+		line, want string
+	}{
+		{"a\t\tb\t", `["a" "" "b" ""]`},
+		{"\ta", `["" "a"]`},
+		{"\t", `["" ""]`},
+	} {
+		out, _, err := execute(context.Background(), While(quoteFields, FieldSeparator("\t")), tt.line+"\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := tt.want + "\n"; out != want {
+			t.Errorf("%q: got %q, want %q", tt.line, out, want)
+		}
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	for _, tt := range []struct {
+		selected SelectFields
+		want     string
+	}{
+		{SelectFields{3, 1}, `["c" "a"]`},
+		{SelectFields{2, 2}, `["b" "b"]`},
+		{SelectFields{1, 5}, `["a" ""]`},
+		{SelectFields{0}, `[""]`},
+	} {
+		out, _, err := execute(context.Background(), While(quoteFields, tt.selected), "a b c\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := tt.want + "\n"; out != want {
+			t.Errorf("%v: got %q, want %q", tt.selected, out, want)
+		}
+	}
+}
+
+func TestNormalizeColumns(t *testing.T) {
+	for _, tt := range []struct {
+		params []any
+		want   string
+	}{
+		{nil, `[" a " "\"b\"" " 'c' " "d"]`},
+		{[]any{TrimColumns{1, 3}}, `["a" "\"b\"" "'c'" "d"]`},
+		{[]any{StripQuotesColumns{2, 3}}, `[" a " "b" " 'c' " "d"]`},
+		{[]any{TrimColumns{3}, StripQuotesColumns{3, 9}}, `[" a " "\"b\"" "c" "d"]`},
+	} {
+		out, _, err := execute(context.Background(), While(quoteFields, append(tt.params, FieldSeparator(","))...), ` a ,"b", 'c' ,d`+"\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := tt.want + "\n"; out != want {
+			t.Errorf("%v: got %q, want %q", tt.params, out, want)
+		}
+	}
+}
+
+func TestUnquote(t *testing.T) {
+	for field, want := range map[string]string{
+		`"a"`:   "a",
+		`'a'`:   "a",
+		`""`:    "",
+		`"a'`:   `"a'`,
+		`"`:     `"`,
+		`""a""`: `"a"`,
+		`a`:     "a",
+	} {
+		if got := unquote(field); got != want {
+			t.Errorf("unquote(%q) = %q, want %q", field, got, want)
+		}
+	}
+}
+
+func TestWarnFieldCountChange(t *testing.T) {
+	out, stderr, err := execute(context.Background(), While(quoteFields, WarnFieldCount), "a b\nc d\ne\nf g h\ni j\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(out, "\n"); n != 5 {
+		t.Errorf("got %d lines of output, want every line processed", n)
+	}
+	want := "while: line 3: 1 fields, first line had 2\n" +
+		"while: line 4: 3 fields, first line had 2\n"
+	if stderr != want {
+		t.Errorf("got %q, want %q", stderr, want)
+	}
+}
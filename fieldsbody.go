@@ -0,0 +1,19 @@
+package command
+
+import gloo "github.com/gloo-foo/framework"
+
+// FieldsBody is called by WhileFields with the fields of each line
+type FieldsBody func(fields []string) gloo.Command
+
+// WhileFields runs body for every input line like While, passing the fields
+// as a []string instead of as arguments. Lines are split on FieldSeparator,
+// or on whitespace when it is not given.
+func WhileFields(body FieldsBody, parameters ...any) gloo.Command {
+	inputs := gloo.Initialize[string, flags](parameters...)
+	return command{
+		handle: func(lineNum int, line string, args []any) gloo.Command {
+			return body(strs(args))
+		},
+		flags: inputs.Flags,
+	}
+}
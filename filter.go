@@ -0,0 +1,20 @@
+package command
+
+import gloo "github.com/gloo-foo/framework"
+
+// WhileFilter runs body, like While, only for the input lines match returns
+// true for, or false for under Invert, like grep piped into while. Other
+// lines count as skipped.
+func WhileFilter(match func(line string) bool, body Body, parameters ...any) gloo.Command {
+	inputs := gloo.Initialize[string, flags](parameters...)
+	invert := bool(inputs.Flags.Invert)
+	return command{
+		handle: func(lineNum int, line string, args []any) gloo.Command {
+			if match(line) == invert {
+				return nil
+			}
+			return body(args...)
+		},
+		flags: inputs.Flags,
+	}
+}
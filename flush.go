@@ -0,0 +1,26 @@
+package command
+
+// flusher is implemented by buffered writers such as *bufio.Writer
+type flusher interface {
+	Flush() error
+}
+
+// flush completes all pending work for FlushOn: it waits for running
+// commands and writes their output, hands any gathered lines to the batch
+// body and flushes stdout if it is buffered
+func (l *loop) flush() error {
+	if l.pool != nil {
+		if err := l.pool.drain(); err != nil {
+			return err
+		}
+	}
+	if l.collector != nil && len(l.collector.lines) > 0 {
+		if err := l.collector.flush(); err != nil {
+			return err
+		}
+	}
+	if f, ok := l.sink.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
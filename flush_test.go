@@ -0,0 +1,45 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+func TestFlushOnFlushesWrappedStdout(t *testing.T) {
+	var sink bytes.Buffer
+	stdout := bufio.NewWriter(&sink)
+
+	// Record how much reached the sink by the time each line runs
+	var flushed []int
+	body := func(args ...any) gloo.Command {
+		flushed = append(flushed, sink.Len())
+		return echo(args...)
+	}
+
+	cmd := While(body, FlushOn("--"), SHA256Checksum)
+	if err := cmd.Executor()(context.Background(), strings.NewReader("a\n--\nb\n"), stdout, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{0, 2}; !slices.Equal(flushed, want) {
+		t.Errorf("sink had %v bytes, want %v", flushed, want)
+	}
+}
+
+func TestFlushOnCompletesBatch(t *testing.T) {
+	body := func(lines []string) gloo.Command { return echo(strings.Join(lines, ",")) }
+
+	out, _, err := execute(context.Background(), WhileBatch(body, 3, FlushOn("--")), "a\nb\n--\nc\nd\ne\nf\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a,b\nc,d,e\nf\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
@@ -0,0 +1,52 @@
+package command
+
+import (
+	"context"
+	"io"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// FoldBody is called by WhileFold with the state from the previous line and
+// the current line, returning the state for the next line and a command
+type FoldBody func(state any, line string) (any, gloo.Command)
+
+type foldCommand struct {
+	command
+	initial any
+	body    FoldBody
+}
+
+// WhileFold runs body for every input line, threading the state it returns
+// into the call for the next line, starting from initial. The command body
+// returns, if not nil, is executed as usual. OnComplete receives the final
+// state once the loop finishes without error.
+func WhileFold(initial any, body FoldBody, parameters ...any) gloo.Command {
+	inputs := gloo.Initialize[string, flags](parameters...)
+	return foldCommand{
+		command: command{flags: inputs.Flags},
+		initial: initial,
+		body:    body,
+	}
+}
+
+func (c foldCommand) Executor() gloo.CommandExecutor {
+	return func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+		state := c.initial
+
+		cmd := c.command
+		cmd.handle = func(lineNum int, line string, args []any) gloo.Command {
+			var next gloo.Command
+			state, next = c.body(state, line)
+			return next
+		}
+		if err := cmd.Executor()(ctx, stdin, stdout, stderr); err != nil {
+			return err
+		}
+
+		if c.flags.OnComplete != nil {
+			c.flags.OnComplete(state)
+		}
+		return nil
+	}
+}
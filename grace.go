@@ -0,0 +1,21 @@
+package command
+
+import (
+	"context"
+	"time"
+)
+
+// graceful returns the context commands run with under ShutdownGrace. It
+// keeps the values of parent but is only cancelled grace after parent is,
+// letting running commands finish. release must be called once the loop
+// is done.
+func graceful(parent context.Context, grace time.Duration) (ctx context.Context, release func()) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(parent))
+	stop := context.AfterFunc(parent, func() {
+		time.AfterFunc(grace, cancel)
+	})
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
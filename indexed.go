@@ -0,0 +1,19 @@
+package command
+
+import gloo "github.com/gloo-foo/framework"
+
+// IndexedBody is called by WhileIndexed with the number and text of each line
+type IndexedBody func(lineNum int, line string) gloo.Command
+
+// WhileIndexed runs body for every input line like While, passing the line
+// number and text instead of its fields. Line numbers start at 1 and count
+// every line read, including those whose body returns nil.
+func WhileIndexed(body IndexedBody, parameters ...any) gloo.Command {
+	inputs := gloo.Initialize[string, flags](parameters...)
+	return command{
+		handle: func(lineNum int, line string, args []any) gloo.Command {
+			return body(lineNum, line)
+		},
+		flags: inputs.Flags,
+	}
+}
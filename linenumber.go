@@ -0,0 +1,14 @@
+package command
+
+import "context"
+
+// LineNumberKey is the context key under which the 1-based number of the
+// line a command runs for is stored. LineNumber reads it.
+type LineNumberKey struct{}
+
+// LineNumber returns the number of the line the command executed with ctx
+// runs for, so nested commands can report it without extra parameters
+func LineNumber(ctx context.Context) (int, bool) {
+	n, ok := ctx.Value(LineNumberKey{}).(int)
+	return n, ok
+}
@@ -0,0 +1,59 @@
+package command
+
+import (
+	"context"
+	"io"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// Line is a single input line as delivered by Lines
+type Line struct {
+	Number int    // 1-based line number
+	Text   string // Line after trimming
+	Args   []any  // Fields parsed according to FieldSeparator
+	Err    error  // Read error; set only on the final value sent
+}
+
+// Lines reads stdin line by line in a background goroutine and delivers each
+// parsed line on the returned channel, closing it at EOF.
+//
+// The channel is unbuffered unless LineBuffer is given, so the next input line
+// is only read once the consumer has taken the previous one. Cancelling ctx
+// unblocks a pending send and stops the goroutine.
+func Lines(ctx context.Context, stdin io.Reader, parameters ...any) <-chan Line {
+	c := command{flags: gloo.Initialize[string, flags](parameters...).Flags}
+	lines := make(chan Line, max(0, int(c.flags.LineBuffer)))
+
+	go func() {
+		defer close(lines)
+
+		send := func(line Line) bool {
+			select {
+			case lines <- line:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		input, err := c.decompress(stdin)
+		if err != nil {
+			send(Line{Err: err})
+			return
+		}
+
+		err = c.readRecords(input, ctx.Err, func(n int, text string) error {
+			text = c.trim(text)
+			if !send(Line{Number: n, Text: text, Args: c.fields(text)}) {
+				return ctx.Err()
+			}
+			return nil
+		})
+		if err != nil && ctx.Err() == nil {
+			send(Line{Err: err})
+		}
+	}()
+
+	return lines
+}
@@ -0,0 +1,44 @@
+package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLinesNegativeBuffer(t *testing.T) {
+	var got []string
+	for line := range Lines(context.Background(), strings.NewReader("a\nb\n"), LineBuffer(-1)) {
+		if line.Err != nil {
+			t.Fatal(line.Err)
+		}
+		got = append(got, line.Text)
+	}
+	if strings.Join(got, ",") != "a,b" {
+		t.Errorf("got %q, want [a b]", got)
+	}
+}
+
+func TestLinesCancelUnblocksSend(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lines := Lines(ctx, strings.NewReader("a\nb\nc\n"))
+
+	if line := <-lines; line.Text != "a" {
+		t.Fatalf("got %q, want a", line.Text)
+	}
+	// The goroutine is now blocked sending b to a consumer that never takes it
+	cancel()
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-lines:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("channel not closed after cancellation")
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package command
+
+import (
+	"context"
+	"io"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// WhileMap writes fn of every input line to stdout, followed by a newline,
+// for plain line-to-line transforms. Nothing is written when fn returns an
+// empty string. An error from fn fails the line, subject to ContinueOnError.
+func WhileMap(fn func(line string) (string, error), parameters ...any) gloo.Command {
+	inputs := gloo.Initialize[string, flags](parameters...)
+	return command{
+		handle: func(lineNum int, line string, args []any) gloo.Command {
+			return gloo.RawCommand(func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+				out, err := fn(line)
+				if err != nil || out == "" {
+					return err
+				}
+				_, err = io.WriteString(stdout, out+"\n")
+				return err
+			})
+		},
+		flags: inputs.Flags,
+	}
+}
@@ -0,0 +1,49 @@
+package command
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+type mapCommand struct {
+	command
+	fn func(fields []string) []string
+}
+
+// MapFields splits each line into fields as configured, passes them to fn
+// and writes the fields it returns as a line, joined by FieldSeparator or
+// a single space when fields are split on whitespace:
+//
+//	MapFields(func(f []string) []string { return []string{f[1], f[0]} })
+//
+// Lines for which fn returns nil are skipped.
+func MapFields(fn func(fields []string) []string, parameters ...any) gloo.Command {
+	inputs := gloo.Initialize[string, flags](parameters...)
+	return mapCommand{
+		command: command{flags: inputs.Flags},
+		fn:      fn,
+	}
+}
+
+func (c mapCommand) Executor() gloo.CommandExecutor {
+	sep := " "
+	if c.flags.FieldSeparator != "" {
+		sep = string(c.flags.FieldSeparator)
+	}
+
+	cmd := c.command
+	cmd.handle = func(lineNum int, line string, args []any) gloo.Command {
+		mapped := c.fn(strs(args))
+		if mapped == nil {
+			return nil
+		}
+		return gloo.RawCommand(func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+			_, err := io.WriteString(stdout, strings.Join(mapped, sep)+"\n")
+			return err
+		})
+	}
+	return cmd.Executor()
+}
@@ -0,0 +1,33 @@
+package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestMapFields(t *testing.T) {
+	swap := func(f []string) []string {
+		if len(f) < 2 {
+			return nil
+		}
+		return []string{strings.ToUpper(f[1]), f[0]}
+	}
+
+	for name, tt := range map[string]struct {
+		params []any
+		input  string
+		want   string
+	}{
+		"whitespace": {nil, "a  b\nonly\nc\td\n", "B a\nD c\n"},
+		"separator":  {[]any{FieldSeparator(",")}, "a,b\nc d,e f\n", "B,a\nE F,c d\n"},
+	} {
+		out, _, err := execute(context.Background(), MapFields(swap, tt.params...), tt.input)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if out != tt.want {
+			t.Errorf("%s: got %q, want %q", name, out, tt.want)
+		}
+	}
+}
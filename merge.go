@@ -0,0 +1,154 @@
+package command
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// ErrUnsorted is returned by WhileMerge under AscendingOrder
+// when an input's keys go backwards
+var ErrUnsorted = errors.New("while: input not sorted by key")
+
+// MergeBody is called by WhileMerge with the fields of the left and right
+// records sharing key. The side without a record is nil.
+type MergeBody func(key string, left, right []string) gloo.Command
+
+type mergeCommand struct {
+	command
+	left, right io.Reader
+	keyField    int
+	body        MergeBody
+}
+
+// WhileMerge reads left and right, both sorted by the keyField field
+// (1-based), and calls body for each key present in both, like a
+// streaming merge join. MergeJoin also passes keys found on one side only.
+// Records with a repeated key are paired in order, with any left over
+// treated as unmatched. AscendingOrder checks the inputs are sorted.
+//
+// Stdin is not read.
+func WhileMerge(left, right io.Reader, keyField int, body MergeBody, parameters ...any) gloo.Command {
+	inputs := gloo.Initialize[string, flags](parameters...)
+	return mergeCommand{
+		command:  command{flags: inputs.Flags},
+		left:     left,
+		right:    right,
+		keyField: keyField,
+		body:     body,
+	}
+}
+
+func (c mergeCommand) Executor() gloo.CommandExecutor {
+	return func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+		l := c.newLoop(ctx, stdout, stderr)
+		return l.finish(stopped(c.run(l)))
+	}
+}
+
+// run merges the two inputs, calling the body for every pair of records
+func (c mergeCommand) run(l *loop) error {
+	left := &cursor{command: c.command, name: "left", keyField: c.keyField, scanner: c.scanner(c.left)}
+	right := &cursor{command: c.command, name: "right", keyField: c.keyField, scanner: c.scanner(c.right)}
+	if err := left.next(); err != nil {
+		return err
+	}
+	if err := right.next(); err != nil {
+		return err
+	}
+
+	join := c.flags.MergeJoin
+	for left.ok || right.ok {
+		var (
+			key      string
+			lf, rf   []string
+			inL, inR bool
+		)
+		switch {
+		case left.ok && right.ok && left.key == right.key:
+			key, lf, rf, inL, inR = left.key, left.fields, right.fields, true, true
+		case !right.ok || left.ok && left.key < right.key:
+			key, lf, inL = left.key, left.fields, true
+		default:
+			key, rf, inR = right.key, right.fields, true
+		}
+
+		if inL {
+			if err := left.next(); err != nil {
+				return err
+			}
+		}
+		if inR {
+			if err := right.next(); err != nil {
+				return err
+			}
+		}
+
+		if inL && !inR && join != LeftJoin && join != FullJoin ||
+			inR && !inL && join != RightJoin && join != FullJoin {
+			continue
+		}
+		if err := c.pair(l, key, lf, rf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pair calls the body for a key and executes the command it returns
+func (c mergeCommand) pair(l *loop, key string, left, right []string) error {
+	if err := l.limits(); err != nil {
+		return err
+	}
+	l.lineNum++
+	l.stats.Lines++
+
+	cmd := c.body(key, left, right)
+	if cmd == nil {
+		l.stats.Skipped++
+		return nil
+	}
+	l.stats.Executed++
+	l.progress()
+
+	if err := l.execute(cmd, result{lineNum: l.lineNum, line: key, text: key}); err != nil {
+		return err
+	}
+	return l.ctx.Err()
+}
+
+// cursor is the current record of one WhileMerge input
+type cursor struct {
+	command
+	name     string
+	keyField int
+	scanner  *bufio.Scanner
+	lineNum  int
+	ok       bool
+	key      string
+	fields   []string
+}
+
+// next advances to the following record, checking its key under AscendingOrder
+func (c *cursor) next() error {
+	if c.ok = c.scanner.Scan(); !c.ok {
+		return readError(c.lineNum+1, c.scanner.Err())
+	}
+	c.lineNum++
+
+	previous := c.key
+	c.fields = c.split(c.trim(c.scanner.Text()))
+	c.key = ""
+	if c.keyField >= 1 && c.keyField <= len(c.fields) {
+		c.key = c.fields[c.keyField-1]
+	}
+
+	if c.flags.Sorted && c.lineNum > 1 && c.key < previous {
+		return fmt.Errorf("%w: %s line %d", ErrUnsorted, c.name, c.lineNum)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// pairs is a MergeBody writing the key and both sides it is called with
+func pairs(key string, left, right []string) gloo.Command {
+	return echo(key, fmt.Sprintf("%q %q", left, right))
+}
+
+func TestWhileMerge(t *testing.T) {
+	left := "a 1\nb 2\nb 3\nd 4\n"
+	right := "b x\nc y\nd z\n"
+
+	for name, tt := range map[string]struct {
+		join MergeJoin
+		want string
+	}{
+		"inner": {InnerJoin, `b ["b" "2"] ["b" "x"]` + "\n" + `d ["d" "4"] ["d" "z"]` + "\n"},
+		"left": {LeftJoin, `a ["a" "1"] []` + "\n" + `b ["b" "2"] ["b" "x"]` + "\n" + `b ["b" "3"] []` + "\n" +
+			`d ["d" "4"] ["d" "z"]` + "\n"},
+		"right": {RightJoin, `b ["b" "2"] ["b" "x"]` + "\n" + `c [] ["c" "y"]` + "\n" + `d ["d" "4"] ["d" "z"]` + "\n"},
+		"full": {FullJoin, `a ["a" "1"] []` + "\n" + `b ["b" "2"] ["b" "x"]` + "\n" + `b ["b" "3"] []` + "\n" +
+			`c [] ["c" "y"]` + "\n" + `d ["d" "4"] ["d" "z"]` + "\n"},
+	} {
+		cmd := WhileMerge(strings.NewReader(left), strings.NewReader(right), 1, pairs, tt.join)
+		out, _, err := execute(context.Background(), cmd, "")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if out != tt.want {
+			t.Errorf("%s: got %q, want %q", name, out, tt.want)
+		}
+	}
+}
+
+func TestWhileMergeUnsorted(t *testing.T) {
+	cmd := WhileMerge(strings.NewReader("a 1\nc 2\nb 3\n"), strings.NewReader("a x\nd y\n"), 1, pairs, FullJoin, AscendingOrder)
+	_, _, err := execute(context.Background(), cmd, "")
+	if !errors.Is(err, ErrUnsorted) {
+		t.Errorf("got %v, want ErrUnsorted", err)
+	}
+}
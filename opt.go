@@ -1,11 +1,1144 @@
 package command
 
+import (
+	"context"
+	"io"
+	"regexp"
+	"time"
+)
+
+// FieldSeparator splits lines into fields at this string instead of at
+// runs of whitespace, for every constructor that hands fields to its body.
+// Empty fields, including leading and trailing ones, are kept, so
+// "a\t\tb\t" split on a tab gives "a", "", "b" and "".
 type FieldSeparator string
 
+// FieldRegexp splits lines into fields at every match of the regular
+// expression. It takes precedence over FieldSeparator if both are given.
+type FieldRegexp struct {
+	*regexp.Regexp
+}
+
+// TrimPrefix removes the given prefix from each line before it is split,
+// if present. It may be given more than once; the first matching prefix wins.
+type TrimPrefix string
+
+// TrimSuffix removes the given suffix from each line before it is split,
+// if present. It may be given more than once; the first matching suffix wins.
+type TrimSuffix string
+
+// LineBuffer sets the capacity of the channel returned by Lines.
+// The default of zero, or any negative value, makes it unbuffered.
+type LineBuffer int
+
+// Repeat runs the whole loop over the input the given number of times.
+// Seekable input is rewound between passes; other input is buffered in memory.
+type Repeat int
+
+// RepeatNumbering controls whether line numbers restart on each Repeat pass
+type RepeatNumbering bool
+
+const (
+	ContinueNumbering RepeatNumbering = false
+	RestartNumbering  RepeatNumbering = true
+)
+
+// MaxBufferedBytes caps how much input may be held in memory by features
+// that need to buffer it. Zero means unlimited.
+type MaxBufferedBytes int64
+
+// SelectFields passes only the listed fields (1-based) to the body, in the
+// given order. Fields may be repeated; out-of-range indices yield "".
+type SelectFields []int
+
+// StrictNoNil makes a body returning nil an error instead of skipping the line
+type StrictNoNil bool
+
+const (
+	SkipNil   StrictNoNil = false
+	RejectNil StrictNoNil = true
+)
+
+// Meta derives metadata from each line, which is handed to OnOutput
+// alongside the output of that line's command
+type Meta func(line string) any
+
+// OnOutput is called with the metadata and captured output of each
+// executed command, before that output is written to stdout
+type OnOutput func(meta any, output []byte)
+
+// Validate checks the input against ExpectFields, RequireNonEmpty and Sorted
+// without calling the body, reporting every violation to stderr
+type Validate bool
+
+const (
+	RunBody      Validate = false
+	ValidateOnly Validate = true
+)
+
+// ExpectFields is the number of fields every line must have under Validate
+type ExpectFields int
+
+// RequireNonEmpty rejects lines with empty fields under Validate
+type RequireNonEmpty bool
+
+const (
+	AllowEmpty  RequireNonEmpty = false
+	RejectEmpty RequireNonEmpty = true
+)
+
+// Sorted rejects lines that sort before the preceding line under Validate,
+// and keys that sort before the preceding key in WhileMerge
+type Sorted bool
+
+const (
+	AnyOrder       Sorted = false
+	AscendingOrder Sorted = true
+)
+
+// Parallelism runs up to n line commands concurrently, buffering each
+// command's output and writing it in input order. Once the context is
+// cancelled no more commands are started, and running ones are waited for.
+type Parallelism int
+
+// AdaptiveParallel runs up to n line commands concurrently, halving the
+// concurrency when a command fails and raising it again by one after a run
+// of successes, never above n. Failures of commands that were already running
+// when it was last halved count as the same burst and do not halve it again.
+// Output is still written in input order.
+type AdaptiveParallel int
+
+// OnlyChanged suppresses output that is the input line unchanged.
+// Output spanning several lines is never suppressed.
+type OnlyChanged bool
+
+const (
+	AllOutput     OnlyChanged = false
+	ChangedOutput OnlyChanged = true
+)
+
+// Semaphore is a weighted semaphore such as *semaphore.Weighted
+// from golang.org/x/sync/semaphore
+type Semaphore interface {
+	Acquire(ctx context.Context, n int64) error
+	Release(n int64)
+}
+
+// SharedSemaphore makes every line command hold one unit of the semaphore
+// while it runs, capping concurrency across all While instances sharing it.
+// Creating and sharing the semaphore is up to the caller.
+type SharedSemaphore struct {
+	Semaphore
+}
+
+// RateReportEvery writes the current throughput in lines per second to
+// stderr at this interval, measured as lines are read
+type RateReportEvery time.Duration
+
+// RateWindow is the sliding window throughput is measured over.
+// It defaults to RateReportEvery.
+type RateWindow time.Duration
+
+// Clock supplies the current time, replacing time.Now
+type Clock func() time.Time
+
+// Fallback is consulted with the same arguments whenever the body returns nil.
+// The line is only skipped, or rejected under StrictNoNil, if it returns nil too.
+type Fallback Body
+
+// TimingCSVWriter writes a CSV row for every executed line to the writer, with
+// the columns line_number, bytes, duration_us and ok. Rows are written in
+// input order, also when running in parallel.
+type TimingCSVWriter struct {
+	io.Writer
+}
+
+// UniqueOutput drops a line's output when it is identical to the output
+// written for the previous line, like piping through uniq
+type UniqueOutput bool
+
+const (
+	RepeatedOutput UniqueOutput = false
+	DistinctOutput UniqueOutput = true
+)
+
+// TrimColumns trims surrounding whitespace from the listed fields (1-based)
+// after splitting. Indices beyond the end of a line are ignored.
+type TrimColumns []int
+
+// StripQuotesColumns removes one pair of surrounding quotes from the listed
+// fields (1-based) after splitting. Indices beyond the end of a line are ignored.
+type StripQuotesColumns []int
+
+// WarnFieldCountChange writes a warning to stderr for every line whose
+// field count differs from the first line's, without stopping the loop
+type WarnFieldCountChange bool
+
+const (
+	IgnoreFieldCount WarnFieldCountChange = false
+	WarnFieldCount   WarnFieldCountChange = true
+)
+
+// Decompressor wraps the input before it is read, for example Gzip or Bzip2.
+// Any other format can be supported by supplying a suitable function.
+type Decompressor func(io.Reader) (io.Reader, error)
+
+// ChecksumOutput computes a SHA-256 digest of everything written to stdout,
+// in output order, and reports it in Stats.Checksum
+type ChecksumOutput bool
+
+const (
+	NoChecksum     ChecksumOutput = false
+	SHA256Checksum ChecksumOutput = true
+)
+
+// ContinueOnError keeps the loop running when a line fails, reporting each
+// failure with its line number to stderr and returning them all at the end
+// as selected by ErrorSummaryMode
+type ContinueOnError bool
+
+const (
+	StopOnError ContinueOnError = false
+	KeepGoing   ContinueOnError = true
+)
+
+// ErrorSummaryMode selects what the error returned under ContinueOnError contains
+type ErrorSummaryMode int
+
+const (
+	AllErrors   ErrorSummaryMode = iota // Every failure, joined
+	FirstError                          // Only the first failure
+	LastError                           // Only the last failure
+	CountErrors                         // Only the number of failures
+)
+
+// AggregateErrors returns every failure under ContinueOnError joined with
+// errors.Join, each annotated with its line number. It is the default.
+const AggregateErrors = AllErrors
+
+// BatchHeader is prepended as the first line of every batch handed to a
+// batch body such as WhileCollect's. It is not counted as an input line.
+type BatchHeader string
+
+// RecordSeparatorRegex splits the input into records at every match of the
+// regular expression instead of at newlines. Separators are not included in
+// the records, and input is matched as it streams in.
+type RecordSeparatorRegex struct {
+	*regexp.Regexp
+}
+
+// AlternateBodies hands lines to the given bodies in rotation instead of
+// the body passed to While: the first line to reach a body goes to the first,
+// the next to the second, and so on, starting over after the last. Lines
+// dropped before then, such as by Skip, SkipEmpty or StartAt, take no turn.
+type AlternateBodies []Body
+
+// MaxDuration stops the loop cleanly before the next line once this much
+// time has passed since it started
+type MaxDuration time.Duration
+
+// OnStop is called with the reason the loop stopped when it finishes
+type OnStop func(reason StopReason)
+
+// ErrorFallback is called with the same arguments when the command for a
+// line fails, and its command run in its place. The line only fails if that
+// command fails too. Output already written by the failed command is kept.
+type ErrorFallback Body
+
+// IncludeLineInError embeds the start of the offending line in each
+// LineError, truncated to keep messages manageable
+type IncludeLineInError bool
+
+const (
+	OmitLineInError  IncludeLineInError = false
+	QuoteLineInError IncludeLineInError = true
+)
+
+// ExternalSort processes the lines in sorted order. Input is sorted in runs
+// of MaxBufferedBytes (64 MiB by default) spilled to temporary files, which
+// are merged, so inputs larger than memory can be sorted.
+type ExternalSort bool
+
+const (
+	InputOrder  ExternalSort = false
+	SortedOrder ExternalSort = true
+)
+
+// TempDir is where ExternalSort writes its temporary files.
+// It defaults to the system temporary directory.
+type TempDir string
+
+// ResourceLimit caps how many resources commands may hold at once through
+// AcquireResource, independently of how many commands run in parallel
+type ResourceLimit int
+
+// ResultWriter writes a JSON object for every executed line to the writer,
+// one per line, with its line number, input, success, error and duration.
+// Records are written in input order, also when running in parallel.
+type ResultWriter struct {
+	io.Writer
+}
+
+// ResultJSONArray makes ResultWriter write a single JSON array of records
+// instead of one object per line. The array is closed even if the loop fails.
+type ResultJSONArray bool
+
+const (
+	ResultLines ResultJSONArray = false
+	ResultArray ResultJSONArray = true
+)
+
+// AutoDetectSeparator chooses between tab, comma and whitespace field
+// separation from the first lines processed, unless FieldSeparator or
+// FieldRegexp is given. The choice is reported in Stats.Separator; when no
+// separator is used consistently it falls back to whitespace with a warning
+// on stderr.
+type AutoDetectSeparator bool
+
+const (
+	FixedSeparator  AutoDetectSeparator = false
+	DetectSeparator AutoDetectSeparator = true
+)
+
+// DeadLetterWriter receives every line that fails under ContinueOnError,
+// exactly as it was read and one per line, so it can be fed back in later
+type DeadLetterWriter struct {
+	io.Writer
+}
+
+// FlushOn is a marker line that is not processed but instead completes all
+// pending work: running commands finish and their output is written, a
+// WhileCollect batch is handed over, and a buffered stdout is flushed
+type FlushOn string
+
+// DiffMaxKeys caps how many distinct keys WhileDiff tracks. A line with a
+// new key beyond the cap fails with ErrTooManyKeys. Zero means unlimited.
+type DiffMaxKeys int
+
+// Retries runs a failing line command again up to this many times
+// before the line counts as failed
+type Retries int
+
+// RetryDelay is how long to wait before each retry
+type RetryDelay time.Duration
+
+// RetryBackoff multiplies RetryDelay by this factor after each retry, so 2
+// doubles the wait every time. Values up to 1 keep the delay constant.
+type RetryBackoff float64
+
+// RetryJitter randomly varies each RetryDelay by up to this fraction of it
+// in either direction, so lines failing together do not retry in lockstep
+type RetryJitter float64
+
+// RetrySeed seeds the randomness of RetryJitter for reproducible delays.
+// Zero picks a random seed.
+type RetrySeed int64
+
+// ExtraOutputs receive a copy of everything written to stdout.
+// It may be given more than once.
+type ExtraOutputs []io.Writer
+
+// IgnoreExtraOutputErrors keeps the loop running when writing to one
+// of the ExtraOutputs fails, instead of returning the error
+type IgnoreExtraOutputErrors bool
+
+const (
+	FailOnExtraOutput IgnoreExtraOutputErrors = false
+	IgnoreExtraOutput IgnoreExtraOutputErrors = true
+)
+
+// UTF8Policy decides what happens to lines that are not valid UTF-8
+type UTF8Policy int
+
+const (
+	UTF8Passthrough UTF8Policy = iota // Lines are processed as they are
+	UTF8Replace                       // Invalid bytes are replaced with U+FFFD
+	UTF8Reject                        // Lines fail with ErrInvalidUTF8
+)
+
+// LookbehindLines bounds how many previous lines any feature may remember,
+// failing with ErrLookbehindLimit beyond it. WhileCollect, WhileBatch and
+// Reverse count every line they hold, Unique every distinct line and
+// WhileDiff one line per distinct key. Zero means unlimited.
+type LookbehindLines int
+
+// TransactionalOrderedParallel runs up to this many line commands at once
+// while applying their results strictly in input order, so the first
+// failure stops every later line from being committed
+type TransactionalOrderedParallel int
+
+// Commit applies the captured output of each successful line in input order,
+// in place of writing it to stdout. An error from Commit stops the loop.
+type Commit func(lineNum int, output []byte) error
+
+// FixedRecordLength reads input as records of exactly this many bytes
+// instead of lines. A shorter final record is processed as it is.
+type FixedRecordLength int
+
+// TrimNullPadding strips trailing NUL bytes from fixed-length records
+type TrimNullPadding bool
+
+const (
+	KeepNullPadding TrimNullPadding = false
+	TrimNulls       TrimNullPadding = true
+)
+
+// StallTimeout reports a line whose command is still running after this
+// long to stderr. The command is left running.
+type StallTimeout time.Duration
+
+// StackDumpOnStall adds the stack traces of every goroutine
+// to the StallTimeout report
+type StackDumpOnStall bool
+
+const (
+	NoStackDump StackDumpOnStall = false
+	DumpStacks  StackDumpOnStall = true
+)
+
+// MaxOutputLines stops the loop once this many lines of output have been
+// written, counting output lines rather than input lines. Output past
+// the limit is dropped.
+type MaxOutputLines int
+
+// MergeJoin selects which keys WhileMerge passes to its body
+type MergeJoin int
+
+const (
+	InnerJoin MergeJoin = iota // Keys present in both inputs
+	LeftJoin                   // Plus keys only in the left input
+	RightJoin                  // Plus keys only in the right input
+	FullJoin                   // Every key in either input
+)
+
+// MaxIterations stops the loop once this many commands have been executed.
+// Lines whose body returns nil do not count. Zero means unlimited.
+type MaxIterations int
+
+// Limit is MaxIterations under the name head users expect. With Skip it
+// selects a window of the input, and reaching it is not an error.
+type Limit = MaxIterations
+
+// Spinner draws a progress line on stderr with the lines read and lines per
+// second, plus an ETA when TotalBytes is known. It is disabled automatically
+// when stderr is not a terminal.
+type Spinner bool
+
+const (
+	NoSpinner   Spinner = false
+	ShowSpinner Spinner = true
+)
+
+// TotalBytes is the expected size of the input, used for the Spinner ETA
+type TotalBytes int64
+
+// PipeLine gives each line's command the line itself, followed by a newline,
+// as stdin instead of empty input
+type PipeLine bool
+
+const (
+	EmptyInput PipeLine = false
+	LineInput  PipeLine = true
+)
+
+// NullDelimited splits input into records at NUL bytes instead of newlines,
+// as written by find -print0
+type NullDelimited bool
+
+const (
+	NewlineDelimited NullDelimited = false
+	NulDelimited     NullDelimited = true
+)
+
+// RecordDelimiter splits input into records at this string instead of
+// newlines, so a record can span several lines. It is stripped from the
+// records, and an empty final record is dropped.
+type RecordDelimiter string
+
+// CSV reads input as CSV records instead of lines, using the first character
+// of FieldSeparator as the delimiter, or a comma. Quoted fields may contain
+// delimiters and newlines, so a record can span several lines.
+type CSV bool
+
+const (
+	PlainText CSV = false
+	CSVInput  CSV = true
+)
+
+// SkipEmpty skips lines that are empty or only whitespace
+// without calling the body
+type SkipEmpty bool
+
+const (
+	KeepEmpty SkipEmpty = false
+	SkipBlank SkipEmpty = true
+)
+
+// CommentPrefix skips lines starting with this prefix, ignoring leading
+// whitespace, without calling the body
+type CommentPrefix string
+
+// TrimCR strips a single trailing carriage return from each record split by
+// NullDelimited, RecordDelimiter, RecordSeparatorRegex or FixedRecordLength.
+// Records split at newlines always drop the carriage return of a CRLF ending.
+type TrimCR bool
+
+const (
+	KeepCR  TrimCR = false
+	StripCR TrimCR = true
+)
+
+// LineTimeout cancels the context of each line's command after this long,
+// failing the line. Whether the loop then stops follows ContinueOnError.
+type LineTimeout time.Duration
+
+// Verbose writes a summary of the lines read, processed and skipped
+// to stderr when the loop finishes
+type Verbose bool
+
+const (
+	Quiet   Verbose = false
+	Summary Verbose = true
+)
+
+// OnComplete is called by WhileFold with the final state
+// once the loop finishes without error
+type OnComplete func(state any)
+
+// MaxLineSize is the longest line, in bytes, that can be read. Longer lines
+// fail with bufio.ErrTooLong. It defaults to bufio.MaxScanTokenSize (64KB).
+type MaxLineSize int
+
+// Skip discards the first n lines of the input, such as a header, without
+// calling the body. They are dropped as they are read, so on every pass
+// under Repeat and before ExternalSort or Reverse reorder the rest, and
+// still count toward line numbers.
+type Skip int
+
+// Invert makes WhileFilter select the lines that do not match
+type Invert bool
+
+const (
+	MatchLines  Invert = false
+	InvertMatch Invert = true
+)
+
+// DryRun reports the command each line would run to stderr, by its String
+// method if it has one, without running it. Skipped lines are reported too.
+type DryRun bool
+
+const (
+	RunCommands DryRun = false
+	Preview     DryRun = true
+)
+
+// Reverse processes the lines from last to first, like tac piped into while.
+// All of the input is held in memory first; line numbers follow the
+// processing order.
+type Reverse bool
+
+const (
+	Forward  Reverse = false
+	Backward Reverse = true
+)
+
+// Unique skips lines identical to any earlier line, like sort -u without the
+// sorting. Every distinct line is remembered, counting against LookbehindLines.
+type Unique bool
+
+const (
+	AllLines      Unique = false
+	DistinctLines Unique = true
+)
+
+// UniqueAdjacent skips lines identical to the line before, like uniq.
+// Only the previous line is remembered.
+type UniqueAdjacent bool
+
+const (
+	AdjacentLines   UniqueAdjacent = false
+	CollapseRepeats UniqueAdjacent = true
+)
+
+// Progress calls Func with the number of commands executed so far every
+// Every commands, as each is started, and once more with the final count
+// when the loop finishes unless that count was just reported
+type Progress struct {
+	Every int
+	Func  func(processed int)
+}
+
+// MaxFields splits lines into at most n fields, like strings.SplitN, so the
+// last field keeps the rest of the line, separators included. It applies to
+// FieldSeparator, FieldRegexp and whitespace splitting; n <= 0 means unlimited.
+type MaxFields int
+
+// Tee receives every line that reaches the body, one per line, whether or
+// not its command writes any output. Failing to write to it stops the loop.
+type Tee struct {
+	io.Writer
+}
+
+// WarnOnSkip reports each line the body returns nil for to stderr,
+// with its line number and the start of its text
+type WarnOnSkip bool
+
+const (
+	QuietSkip WarnOnSkip = false
+	WarnSkip  WarnOnSkip = true
+)
+
+// ShutdownGrace lets commands already running when the context is cancelled
+// go on for up to this long, and writes their output in order, before their
+// own context is cancelled too. No new lines are started meanwhile.
+type ShutdownGrace time.Duration
+
+// OnError decides what happens when a line fails, in place of
+// ContinueOnError: returning nil moves on to the next line, while any
+// other error stops the loop with it. line is the line as read.
+type OnError func(lineNum int, line string, err error) error
+
+// StartAt skips lines until the first one it returns true for, like
+// sed '/START/,$', for input with a preamble of varying length. Under Repeat
+// every pass starts over.
+type StartAt func(line string) bool
+
+// ExcludeStart skips the line StartAt matched as well
+type ExcludeStart bool
+
+const (
+	IncludeStartLine ExcludeStart = false
+	ExcludeStartLine ExcludeStart = true
+)
+
+// StopAt ends the loop without error at the first line it returns true
+// for, after any StartAt line, so with StartAt it selects a section of
+// the input. The matching line is processed last unless ExcludeStop is set.
+type StopAt func(line string) bool
+
+// ExcludeStop stops before the line StopAt matched instead of after it
+type ExcludeStop bool
+
+const (
+	IncludeStopLine ExcludeStop = false
+	ExcludeStopLine ExcludeStop = true
+)
+
+// MinLineLen skips lines shorter than this without calling the body.
+// Lengths are in bytes, after any trimming, unless LineLenRunes is set.
+type MinLineLen int
+
+// MaxLineLenFilter skips lines longer than this without calling the body.
+// Unlike MaxLineSize, which fails the read, the line is just skipped.
+type MaxLineLenFilter int
+
+// LineLenRunes measures MinLineLen and MaxLineLenFilter in runes
+type LineLenRunes bool
+
+const (
+	ByteLength LineLenRunes = false
+	RuneLength LineLenRunes = true
+)
+
 type flags struct {
-	FieldSeparator FieldSeparator
+	FieldSeparator               FieldSeparator
+	TrimPrefix                   []TrimPrefix
+	TrimSuffix                   []TrimSuffix
+	LineBuffer                   LineBuffer
+	Repeat                       Repeat
+	RepeatNumbering              RepeatNumbering
+	MaxBufferedBytes             MaxBufferedBytes
+	SelectFields                 SelectFields
+	StrictNoNil                  StrictNoNil
+	Meta                         Meta
+	OnOutput                     OnOutput
+	Validate                     Validate
+	ExpectFields                 ExpectFields
+	RequireNonEmpty              RequireNonEmpty
+	Sorted                       Sorted
+	AdaptiveParallel             AdaptiveParallel
+	Stats                        *Stats
+	OnlyChanged                  OnlyChanged
+	SharedSemaphore              SharedSemaphore
+	RateReportEvery              RateReportEvery
+	RateWindow                   RateWindow
+	Clock                        Clock
+	Fallback                     Fallback
+	TimingCSVWriter              TimingCSVWriter
+	UniqueOutput                 UniqueOutput
+	TrimColumns                  TrimColumns
+	StripQuotesColumns           StripQuotesColumns
+	WarnFieldCountChange         WarnFieldCountChange
+	Decompressor                 Decompressor
+	ChecksumOutput               ChecksumOutput
+	ContinueOnError              ContinueOnError
+	ErrorSummaryMode             ErrorSummaryMode
+	BatchHeader                  BatchHeader
+	RecordSeparatorRegex         RecordSeparatorRegex
+	AlternateBodies              AlternateBodies
+	MaxDuration                  MaxDuration
+	OnStop                       OnStop
+	ErrorFallback                ErrorFallback
+	IncludeLineInError           IncludeLineInError
+	ExternalSort                 ExternalSort
+	TempDir                      TempDir
+	ResourceLimit                ResourceLimit
+	ResultWriter                 ResultWriter
+	ResultJSONArray              ResultJSONArray
+	AutoDetectSeparator          AutoDetectSeparator
+	DeadLetterWriter             DeadLetterWriter
+	FlushOn                      FlushOn
+	DiffMaxKeys                  DiffMaxKeys
+	Retries                      Retries
+	RetryDelay                   RetryDelay
+	RetryBackoff                 RetryBackoff
+	RetryJitter                  RetryJitter
+	RetrySeed                    RetrySeed
+	ExtraOutputs                 ExtraOutputs
+	IgnoreExtraOutputErrors      IgnoreExtraOutputErrors
+	UTF8Policy                   UTF8Policy
+	LookbehindLines              LookbehindLines
+	TransactionalOrderedParallel TransactionalOrderedParallel
+	Commit                       Commit
+	FixedRecordLength            FixedRecordLength
+	TrimNullPadding              TrimNullPadding
+	StallTimeout                 StallTimeout
+	StackDumpOnStall             StackDumpOnStall
+	MaxOutputLines               MaxOutputLines
+	MergeJoin                    MergeJoin
+	MaxIterations                MaxIterations
+	Spinner                      Spinner
+	TotalBytes                   TotalBytes
+	PipeLine                     PipeLine
+	Parallelism                  Parallelism
+	NullDelimited                NullDelimited
+	RecordDelimiter              RecordDelimiter
+	FieldRegexp                  FieldRegexp
+	CSV                          CSV
+	SkipEmpty                    SkipEmpty
+	CommentPrefix                CommentPrefix
+	TrimCR                       TrimCR
+	LineTimeout                  LineTimeout
+	Verbose                      Verbose
+	OnComplete                   OnComplete
+	MaxLineSize                  MaxLineSize
+	Skip                         Skip
+	Invert                       Invert
+	DryRun                       DryRun
+	Reverse                      Reverse
+	Unique                       Unique
+	UniqueAdjacent               UniqueAdjacent
+	Progress                     Progress
+	MaxFields                    MaxFields
+	Tee                          Tee
+	WarnOnSkip                   WarnOnSkip
+	ShutdownGrace                ShutdownGrace
+	OnError                      OnError
+	StartAt                      StartAt
+	ExcludeStart                 ExcludeStart
+	StopAt                       StopAt
+	ExcludeStop                  ExcludeStop
+	MinLineLen                   MinLineLen
+	MaxLineLenFilter             MaxLineLenFilter
+	LineLenRunes                 LineLenRunes
 }
 
 func (f FieldSeparator) Configure(flags *flags) {
 	flags.FieldSeparator = f
 }
+
+func (f TrimPrefix) Configure(flags *flags) {
+	flags.TrimPrefix = append(flags.TrimPrefix, f)
+}
+
+func (f TrimSuffix) Configure(flags *flags) {
+	flags.TrimSuffix = append(flags.TrimSuffix, f)
+}
+
+func (f LineBuffer) Configure(flags *flags) {
+	flags.LineBuffer = f
+}
+
+func (f Repeat) Configure(flags *flags) {
+	flags.Repeat = f
+}
+
+func (f RepeatNumbering) Configure(flags *flags) {
+	flags.RepeatNumbering = f
+}
+
+func (f MaxBufferedBytes) Configure(flags *flags) {
+	flags.MaxBufferedBytes = f
+}
+
+func (f SelectFields) Configure(flags *flags) {
+	flags.SelectFields = f
+}
+
+func (f StrictNoNil) Configure(flags *flags) {
+	flags.StrictNoNil = f
+}
+
+func (f Meta) Configure(flags *flags) {
+	flags.Meta = f
+}
+
+func (f OnOutput) Configure(flags *flags) {
+	flags.OnOutput = f
+}
+
+func (f Validate) Configure(flags *flags) {
+	flags.Validate = f
+}
+
+func (f ExpectFields) Configure(flags *flags) {
+	flags.ExpectFields = f
+}
+
+func (f RequireNonEmpty) Configure(flags *flags) {
+	flags.RequireNonEmpty = f
+}
+
+func (f Sorted) Configure(flags *flags) {
+	flags.Sorted = f
+}
+
+func (f AdaptiveParallel) Configure(flags *flags) {
+	flags.AdaptiveParallel = f
+}
+
+func (f *Stats) Configure(flags *flags) {
+	flags.Stats = f
+}
+
+func (f OnlyChanged) Configure(flags *flags) {
+	flags.OnlyChanged = f
+}
+
+func (f SharedSemaphore) Configure(flags *flags) {
+	flags.SharedSemaphore = f
+}
+
+func (f RateReportEvery) Configure(flags *flags) {
+	flags.RateReportEvery = f
+}
+
+func (f RateWindow) Configure(flags *flags) {
+	flags.RateWindow = f
+}
+
+func (f Clock) Configure(flags *flags) {
+	flags.Clock = f
+}
+
+func (f Fallback) Configure(flags *flags) {
+	flags.Fallback = f
+}
+
+func (f TimingCSVWriter) Configure(flags *flags) {
+	flags.TimingCSVWriter = f
+}
+
+func (f UniqueOutput) Configure(flags *flags) {
+	flags.UniqueOutput = f
+}
+
+func (f TrimColumns) Configure(flags *flags) {
+	flags.TrimColumns = f
+}
+
+func (f StripQuotesColumns) Configure(flags *flags) {
+	flags.StripQuotesColumns = f
+}
+
+func (f WarnFieldCountChange) Configure(flags *flags) {
+	flags.WarnFieldCountChange = f
+}
+
+func (f Decompressor) Configure(flags *flags) {
+	flags.Decompressor = f
+}
+
+func (f ChecksumOutput) Configure(flags *flags) {
+	flags.ChecksumOutput = f
+}
+
+func (f ContinueOnError) Configure(flags *flags) {
+	flags.ContinueOnError = f
+}
+
+func (f ErrorSummaryMode) Configure(flags *flags) {
+	flags.ErrorSummaryMode = f
+}
+
+func (f BatchHeader) Configure(flags *flags) {
+	flags.BatchHeader = f
+}
+
+func (f RecordSeparatorRegex) Configure(flags *flags) {
+	flags.RecordSeparatorRegex = f
+}
+
+func (f AlternateBodies) Configure(flags *flags) {
+	flags.AlternateBodies = f
+}
+
+func (f MaxDuration) Configure(flags *flags) {
+	flags.MaxDuration = f
+}
+
+func (f OnStop) Configure(flags *flags) {
+	flags.OnStop = f
+}
+
+func (f ErrorFallback) Configure(flags *flags) {
+	flags.ErrorFallback = f
+}
+
+func (f IncludeLineInError) Configure(flags *flags) {
+	flags.IncludeLineInError = f
+}
+
+func (f ExternalSort) Configure(flags *flags) {
+	flags.ExternalSort = f
+}
+
+func (f TempDir) Configure(flags *flags) {
+	flags.TempDir = f
+}
+
+func (f ResourceLimit) Configure(flags *flags) {
+	flags.ResourceLimit = f
+}
+
+func (f ResultWriter) Configure(flags *flags) {
+	flags.ResultWriter = f
+}
+
+func (f ResultJSONArray) Configure(flags *flags) {
+	flags.ResultJSONArray = f
+}
+
+func (f AutoDetectSeparator) Configure(flags *flags) {
+	flags.AutoDetectSeparator = f
+}
+
+func (f DeadLetterWriter) Configure(flags *flags) {
+	flags.DeadLetterWriter = f
+}
+
+func (f FlushOn) Configure(flags *flags) {
+	flags.FlushOn = f
+}
+
+func (f DiffMaxKeys) Configure(flags *flags) {
+	flags.DiffMaxKeys = f
+}
+
+func (f Retries) Configure(flags *flags) {
+	flags.Retries = f
+}
+
+func (f RetryDelay) Configure(flags *flags) {
+	flags.RetryDelay = f
+}
+
+func (f RetryBackoff) Configure(flags *flags) {
+	flags.RetryBackoff = f
+}
+
+func (f RetryJitter) Configure(flags *flags) {
+	flags.RetryJitter = f
+}
+
+func (f RetrySeed) Configure(flags *flags) {
+	flags.RetrySeed = f
+}
+
+func (f ExtraOutputs) Configure(flags *flags) {
+	flags.ExtraOutputs = append(flags.ExtraOutputs, f...)
+}
+
+func (f IgnoreExtraOutputErrors) Configure(flags *flags) {
+	flags.IgnoreExtraOutputErrors = f
+}
+
+func (f UTF8Policy) Configure(flags *flags) {
+	flags.UTF8Policy = f
+}
+
+func (f LookbehindLines) Configure(flags *flags) {
+	flags.LookbehindLines = f
+}
+
+func (f TransactionalOrderedParallel) Configure(flags *flags) {
+	flags.TransactionalOrderedParallel = f
+}
+
+func (f Commit) Configure(flags *flags) {
+	flags.Commit = f
+}
+
+func (f FixedRecordLength) Configure(flags *flags) {
+	flags.FixedRecordLength = f
+}
+
+func (f TrimNullPadding) Configure(flags *flags) {
+	flags.TrimNullPadding = f
+}
+
+func (f StallTimeout) Configure(flags *flags) {
+	flags.StallTimeout = f
+}
+
+func (f StackDumpOnStall) Configure(flags *flags) {
+	flags.StackDumpOnStall = f
+}
+
+func (f MaxOutputLines) Configure(flags *flags) {
+	flags.MaxOutputLines = f
+}
+
+func (f MergeJoin) Configure(flags *flags) {
+	flags.MergeJoin = f
+}
+
+func (f MaxIterations) Configure(flags *flags) {
+	flags.MaxIterations = f
+}
+
+func (f Spinner) Configure(flags *flags) {
+	flags.Spinner = f
+}
+
+func (f TotalBytes) Configure(flags *flags) {
+	flags.TotalBytes = f
+}
+
+func (f PipeLine) Configure(flags *flags) {
+	flags.PipeLine = f
+}
+
+func (f Parallelism) Configure(flags *flags) {
+	flags.Parallelism = f
+}
+
+func (f NullDelimited) Configure(flags *flags) {
+	flags.NullDelimited = f
+}
+
+func (f RecordDelimiter) Configure(flags *flags) {
+	flags.RecordDelimiter = f
+}
+
+func (f FieldRegexp) Configure(flags *flags) {
+	flags.FieldRegexp = f
+}
+
+func (f CSV) Configure(flags *flags) {
+	flags.CSV = f
+}
+
+func (f SkipEmpty) Configure(flags *flags) {
+	flags.SkipEmpty = f
+}
+
+func (f CommentPrefix) Configure(flags *flags) {
+	flags.CommentPrefix = f
+}
+
+func (f TrimCR) Configure(flags *flags) {
+	flags.TrimCR = f
+}
+
+func (f LineTimeout) Configure(flags *flags) {
+	flags.LineTimeout = f
+}
+
+func (f Verbose) Configure(flags *flags) {
+	flags.Verbose = f
+}
+
+func (f OnComplete) Configure(flags *flags) {
+	flags.OnComplete = f
+}
+
+func (f MaxLineSize) Configure(flags *flags) {
+	flags.MaxLineSize = f
+}
+
+func (f Skip) Configure(flags *flags) {
+	flags.Skip = f
+}
+
+func (f Invert) Configure(flags *flags) {
+	flags.Invert = f
+}
+
+func (f DryRun) Configure(flags *flags) {
+	flags.DryRun = f
+}
+
+func (f Reverse) Configure(flags *flags) {
+	flags.Reverse = f
+}
+
+func (f Unique) Configure(flags *flags) {
+	flags.Unique = f
+}
+
+func (f UniqueAdjacent) Configure(flags *flags) {
+	flags.UniqueAdjacent = f
+}
+
+func (f Progress) Configure(flags *flags) {
+	flags.Progress = f
+}
+
+func (f MaxFields) Configure(flags *flags) {
+	flags.MaxFields = f
+}
+
+func (f Tee) Configure(flags *flags) {
+	flags.Tee = f
+}
+
+func (f WarnOnSkip) Configure(flags *flags) {
+	flags.WarnOnSkip = f
+}
+
+func (f ShutdownGrace) Configure(flags *flags) {
+	flags.ShutdownGrace = f
+}
+
+func (f OnError) Configure(flags *flags) {
+	flags.OnError = f
+}
+
+func (f StartAt) Configure(flags *flags) {
+	flags.StartAt = f
+}
+
+func (f ExcludeStart) Configure(flags *flags) {
+	flags.ExcludeStart = f
+}
+
+func (f StopAt) Configure(flags *flags) {
+	flags.StopAt = f
+}
+
+func (f ExcludeStop) Configure(flags *flags) {
+	flags.ExcludeStop = f
+}
+
+func (f MinLineLen) Configure(flags *flags) {
+	flags.MinLineLen = f
+}
+
+func (f MaxLineLenFilter) Configure(flags *flags) {
+	flags.MaxLineLenFilter = f
+}
+
+func (f LineLenRunes) Configure(flags *flags) {
+	flags.LineLenRunes = f
+}
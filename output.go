@@ -0,0 +1,71 @@
+package command
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// fanOut returns a writer copying everything written to stdout
+// to the ExtraOutputs too
+func (c command) fanOut(stdout io.Writer) io.Writer {
+	writers := []io.Writer{stdout}
+	for _, w := range c.flags.ExtraOutputs {
+		if c.flags.IgnoreExtraOutputErrors {
+			w = ignoreErrors{w}
+		}
+		writers = append(writers, w)
+	}
+	return io.MultiWriter(writers...)
+}
+
+// ignoreErrors is a writer that never fails
+type ignoreErrors struct {
+	w io.Writer
+}
+
+func (w ignoreErrors) Write(p []byte) (int, error) {
+	w.w.Write(p)
+	return len(p), nil
+}
+
+// capped is a writer passing through only the first remaining lines,
+// silently dropping the rest
+type capped struct {
+	w         io.Writer
+	remaining int
+}
+
+func (w *capped) Write(p []byte) (int, error) {
+	n := len(p)
+	if w.remaining <= 0 {
+		return n, nil
+	}
+
+	end := 0
+	for w.remaining > 0 && end < len(p) {
+		i := bytes.IndexByte(p[end:], '\n')
+		if i < 0 {
+			end = len(p)
+			break
+		}
+		end += i + 1
+		w.remaining--
+	}
+	if _, err := w.w.Write(p[:end]); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// locked is a writer serializing writes from concurrent goroutines
+type locked struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *locked) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
@@ -0,0 +1,91 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+func TestChecksumOutput(t *testing.T) {
+	body := func(args ...any) gloo.Command { return echo(args...) }
+	checksum := func(input string, params ...any) (string, string) {
+		t.Helper()
+		var stats Stats
+		out, _, err := execute(context.Background(), While(body, append(params, SHA256Checksum, &stats)...), input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out, stats.Checksum
+	}
+
+	out, first := checksum("a\nb\n")
+	sum := sha256.Sum256([]byte(out))
+	if want := hex.EncodeToString(sum[:]); first != want {
+		t.Errorf("got %s, want the digest of the output %s", first, want)
+	}
+	if _, again := checksum("a\nb\n"); again != first {
+		t.Errorf("same input: got %s, then %s", first, again)
+	}
+	if _, parallel := checksum("a\nb\n", Parallelism(2)); parallel != first {
+		t.Errorf("parallel: got %s, want %s", parallel, first)
+	}
+	if _, changed := checksum("a\nc\n"); changed == first {
+		t.Error("changed line: digest unchanged")
+	}
+}
+
+// brokenWriter is a writer that always fails
+type brokenWriter struct{}
+
+func (brokenWriter) Write(p []byte) (int, error) { return 0, errors.New("broken") }
+
+func TestExtraOutputs(t *testing.T) {
+	body := func(args ...any) gloo.Command { return echo(args...) }
+	input := "a\nb\nc\n"
+
+	var first, second bytes.Buffer
+	out, _, err := execute(context.Background(), While(body, ExtraOutputs{&first}, ExtraOutputs{&second}), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != input || first.String() != input || second.String() != input {
+		t.Errorf("got %q, %q and %q, want %q in each", out, first.String(), second.String(), input)
+	}
+
+	_, _, err = execute(context.Background(), While(body, ExtraOutputs{brokenWriter{}}), input)
+	if err == nil {
+		t.Error("got nil error writing to a broken extra output")
+	}
+
+	var after bytes.Buffer
+	out, _, err = execute(context.Background(), While(body, ExtraOutputs{brokenWriter{}, &after}, IgnoreExtraOutput), input)
+	if err != nil {
+		t.Fatalf("IgnoreExtraOutput: %v", err)
+	}
+	if out != input || after.String() != input {
+		t.Errorf("IgnoreExtraOutput: got %q and %q, want %q in both", out, after.String(), input)
+	}
+}
+
+func TestMaxOutputLines(t *testing.T) {
+	// Every input line writes two output lines in a single write
+	body := func(args ...any) gloo.Command { return echo(fmt.Sprintf("%v.1\n%v.2", args[0], args[0])) }
+
+	var stats Stats
+	out, _, err := execute(context.Background(), While(body, MaxOutputLines(3), &stats), "a\nb\nc\nd\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a.1\na.2\nb.1\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+	if stats.Executed != 2 || stats.StopReason != StopMaxOutput {
+		t.Errorf("executed %d, stopped by %s; want 2, max-output", stats.Executed, stats.StopReason)
+	}
+}
@@ -0,0 +1,141 @@
+package command
+
+import (
+	"bytes"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// job is a command running concurrently for a single line
+type job struct {
+	result
+	cmd    gloo.Command
+	output bytes.Buffer
+	done   bool
+	seq    int // Order of dispatch, from 1
+}
+
+// pool runs line commands concurrently while writing their output in input order.
+// Each job buffers its own output, and pending is only drained from the front
+// once the job there is done, so stdout matches a sequential run byte for byte.
+// Only the loop goroutine touches the pool; workers report back on finished.
+type pool struct {
+	l        *loop
+	limit    int // Current concurrency
+	max      int // Configured concurrency
+	adaptive bool
+	streak   int // Successes since the limit last changed
+	seq      int // Jobs dispatched so far
+	cut      int // Jobs dispatched when the limit was last halved
+	inFlight int
+	pending  []*job // Dispatched jobs not yet written, in input order
+	finished chan *job
+}
+
+func newPool(l *loop, n int, adaptive bool) *pool {
+	return &pool{
+		l:        l,
+		limit:    n,
+		max:      n,
+		adaptive: adaptive,
+		finished: make(chan *job, n),
+	}
+}
+
+// dispatch starts cmd once a slot is free
+func (p *pool) dispatch(r result, cmd gloo.Command) error {
+	for p.inFlight >= p.limit {
+		if err := p.wait(); err != nil {
+			return err
+		}
+	}
+
+	// Check only once a slot is free, as waiting for one can take a while
+	if err := p.l.ctx.Err(); err != nil {
+		return err
+	}
+
+	p.seq++
+	j := &job{result: r, cmd: cmd, seq: p.seq}
+	p.inFlight++
+	p.pending = append(p.pending, j)
+	go func() {
+		j.elapsed, j.err = p.l.timed(j.cmd, &j.output, j.lineNum)
+		p.finished <- j
+	}()
+	return nil
+}
+
+// wait blocks until a running job finishes, then writes out every
+// job at the front of the queue that is done
+func (p *pool) wait() error {
+	j := <-p.finished
+	j.done = true
+	p.inFlight--
+	err := j.err
+	if control(err) {
+		// Loop control is not a failure
+		err = nil
+	}
+	p.adjust(j.seq, err)
+
+	for len(p.pending) > 0 && p.pending[0].done {
+		j, p.pending = p.pending[0], p.pending[1:]
+		j.result.output = j.output.Bytes()
+		if err := p.l.emit(j.result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// adjust applies AIMD control to the limit after the job dispatched seq-th
+// finishes: halve it on failure and grow it by one after as many consecutive
+// successes as the current limit. Jobs already running when the limit was
+// last halved belong to the same burst of failures, so they cannot halve it
+// again, however many of them fail.
+func (p *pool) adjust(seq int, err error) {
+	if !p.adaptive {
+		return
+	}
+
+	if err != nil {
+		if seq > p.cut {
+			p.limit = max(1, p.limit/2)
+			p.cut = p.seq
+		}
+		p.streak = 0
+		return
+	}
+
+	p.streak++
+	if p.streak >= p.limit && p.limit < p.max {
+		p.limit++
+		p.streak = 0
+	}
+}
+
+// drain waits for every running job and writes its output
+func (p *pool) drain() error {
+	for p.inFlight > 0 {
+		if err := p.wait(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// close waits for every running job. If err is nil the remaining output is
+// written and the first failure is returned; otherwise their output is
+// discarded, unless ShutdownGrace lets them finish writing.
+func (p *pool) close(err error) error {
+	for p.inFlight > 0 {
+		if err != nil && p.l.flags.ShutdownGrace <= 0 {
+			p.pending = nil
+		}
+		if werr := p.wait(); err == nil {
+			err = werr
+		}
+	}
+	return err
+}
@@ -0,0 +1,264 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+func TestParallelSharesStderr(t *testing.T) {
+	body := func(args ...any) gloo.Command {
+		return gloo.RawCommand(func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+			fmt.Fprintf(stderr, "line %v\n", args[0])
+			if args[0] == "3" {
+				return errors.New("failed")
+			}
+			return nil
+		})
+	}
+
+	_, stderr, err := execute(context.Background(), While(body, Parallelism(4), KeepGoing), "1\n2\n3\n4\n5\n6\n")
+	if err == nil {
+		t.Fatal("got nil, want the failure of line 3")
+	}
+	for _, want := range []string{"line 1\n", "line 6\n", "while: line 3: failed\n"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr %q lacks %q", stderr, want)
+		}
+	}
+}
+
+func TestParallelPreservesOrder(t *testing.T) {
+	var input strings.Builder
+	for i := 1; i <= 200; i++ {
+		fmt.Fprintln(&input, i)
+	}
+
+	// Later lines often finish first
+	random := rand.New(rand.NewPCG(1, 2))
+	delays := make([]time.Duration, 201)
+	for i := range delays {
+		delays[i] = time.Duration(random.IntN(2000)) * time.Microsecond
+	}
+	body := func(args ...any) gloo.Command {
+		return gloo.RawCommand(func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+			n, _ := strconv.Atoi(args[0].(string))
+			time.Sleep(delays[n])
+			_, err := fmt.Fprintf(stdout, "line %d\n", n)
+			return err
+		})
+	}
+
+	want, _, err := execute(context.Background(), While(body), input.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{2, 8, 64} {
+		got, _, err := execute(context.Background(), While(body, Parallelism(n)), input.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Parallelism(%d): output differs from the sequential run", n)
+		}
+	}
+}
+
+func TestAdaptiveParallelIgnoresLoopControl(t *testing.T) {
+	body := func(args ...any) gloo.Command { return fails(ErrContinue) }
+
+	var stats Stats
+	if _, _, err := execute(context.Background(), While(body, AdaptiveParallel(8), &stats), strings.Repeat("a\n", 50)); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Concurrency != 8 {
+		t.Errorf("got concurrency %d, want 8", stats.Concurrency)
+	}
+}
+
+func TestCancelMidStream(t *testing.T) {
+	for _, tt := range []struct {
+		parallelism int
+		started     int64
+	}{
+		{1, 3},
+		// Lines 1 to 4 are running when line 3 cancels, and line 5 never starts
+		{4, 4},
+	} {
+		ctx, cancel := context.WithCancel(context.Background())
+		var started atomic.Int64
+		body := func(args ...any) gloo.Command {
+			return gloo.RawCommand(func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+				started.Add(1)
+				if args[0] == "3" {
+					cancel()
+				} else if tt.parallelism > 1 {
+					<-ctx.Done()
+				}
+				return nil
+			})
+		}
+
+		_, _, err := execute(ctx, While(body, Parallelism(tt.parallelism)), strings.Repeat("1\n2\n3\n4\n5\n6\n", 2))
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Parallelism(%d): got %v, want context.Canceled", tt.parallelism, err)
+		}
+		if n := started.Load(); n != tt.started {
+			t.Errorf("Parallelism(%d): %d commands started, want %d", tt.parallelism, n, tt.started)
+		}
+		cancel()
+	}
+}
+
+func TestAdaptiveParallelHalvesOncePerBurst(t *testing.T) {
+	p := newPool(&loop{}, 8, true)
+	failed := errors.New("failed")
+
+	// Eight jobs running when the first fails
+	p.seq = 8
+	p.adjust(1, failed)
+	p.adjust(2, failed)
+	p.adjust(8, failed)
+	if p.limit != 4 {
+		t.Fatalf("after one burst: limit %d, want 4", p.limit)
+	}
+
+	// A job started after the cut fails
+	p.seq = 9
+	p.adjust(9, failed)
+	if p.limit != 2 {
+		t.Fatalf("after a second burst: limit %d, want 2", p.limit)
+	}
+
+	// 2+3+...+7 successes bring it back up to the maximum
+	for i := 0; i < 27; i++ {
+		p.adjust(10+i, nil)
+	}
+	if p.limit != 8 {
+		t.Errorf("after successes: limit %d, want 8", p.limit)
+	}
+}
+
+func TestAdaptiveParallelRecovers(t *testing.T) {
+	body := func(args ...any) gloo.Command {
+		if args[0] == "fail" {
+			return fails(errors.New("failed"))
+		}
+		return echo(args...)
+	}
+
+	for _, tt := range []struct {
+		input string
+		want  int
+	}{
+		{strings.Repeat("fail\n", 8), 4},
+		{strings.Repeat("fail\n", 8) + strings.Repeat("ok\n", 100), 8},
+	} {
+		var stats Stats
+		_, _, err := execute(context.Background(), While(body, AdaptiveParallel(8), KeepGoing, &stats), tt.input)
+		if err == nil {
+			t.Fatal("got nil, want the failures")
+		}
+		if stats.Concurrency != tt.want {
+			t.Errorf("%d lines: concurrency %d, want %d", strings.Count(tt.input, "\n"), stats.Concurrency, tt.want)
+		}
+	}
+}
+
+// chanSemaphore is a Semaphore built on a buffered channel
+type chanSemaphore chan struct{}
+
+func (s chanSemaphore) Acquire(ctx context.Context, n int64) error {
+	for ; n > 0; n-- {
+		select {
+		case s <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (s chanSemaphore) Release(n int64) {
+	for ; n > 0; n-- {
+		<-s
+	}
+}
+
+func TestSharedSemaphore(t *testing.T) {
+	var running, peak atomic.Int64
+	body := func(args ...any) gloo.Command {
+		return gloo.RawCommand(func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			time.Sleep(time.Millisecond)
+			return nil
+		})
+	}
+
+	sem := SharedSemaphore{make(chanSemaphore, 2)}
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, _, err := execute(context.Background(), While(body, Parallelism(4), sem), strings.Repeat("x\n", 50))
+			errs <- err
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if p := peak.Load(); p > 2 {
+		t.Errorf("%d commands ran at once, want at most 2", p)
+	}
+}
+
+func TestTransactionalOrderedParallel(t *testing.T) {
+	body := func(args ...any) gloo.Command {
+		n, _ := strconv.Atoi(fmt.Sprint(args[0]))
+		return gloo.RawCommand(func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+			// Later lines finish first
+			time.Sleep(time.Duration(8-n) * time.Millisecond)
+			if n == 3 {
+				return errors.New("failed")
+			}
+			_, err := fmt.Fprintln(stdout, n)
+			return err
+		})
+	}
+
+	var committed []int
+	var outputs []string
+	commit := Commit(func(lineNum int, output []byte) error {
+		committed = append(committed, lineNum)
+		outputs = append(outputs, string(output))
+		return nil
+	})
+
+	out, _, err := execute(context.Background(), While(body, TransactionalOrderedParallel(4), commit), "1\n2\n3\n4\n5\n6\n")
+	if err == nil {
+		t.Fatal("got nil, want the failure of line 3")
+	}
+	if out != "" {
+		t.Errorf("stdout %q, want nothing with Commit", out)
+	}
+	if want := []int{1, 2}; !slices.Equal(committed, want) {
+		t.Errorf("committed lines %v, want %v", committed, want)
+	}
+	if want := []string{"1\n", "2\n"}; !slices.Equal(outputs, want) {
+		t.Errorf("committed %q, want %q", outputs, want)
+	}
+}
@@ -0,0 +1,29 @@
+package command
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// piped runs a command with its line as stdin, for PipeLine
+type piped struct {
+	cmd  gloo.Command
+	line string
+}
+
+func (c piped) Executor() gloo.CommandExecutor {
+	return func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+		return c.cmd.Executor()(ctx, strings.NewReader(c.line+"\n"), stdout, stderr)
+	}
+}
+
+// pipe gives cmd the line as its stdin under PipeLine
+func (c command) pipe(cmd gloo.Command, line string) gloo.Command {
+	if !c.flags.PipeLine || cmd == nil {
+		return cmd
+	}
+	return piped{cmd: cmd, line: line}
+}
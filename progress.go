@@ -0,0 +1,13 @@
+package command
+
+// progress calls Progress each time another Every commands have been executed
+func (l *loop) progress() {
+	every := l.flags.Progress.Every
+	if every <= 0 || l.flags.Progress.Func == nil {
+		return
+	}
+	for l.reported+every <= l.stats.Executed {
+		l.reported += every
+		l.flags.Progress.Func(l.reported)
+	}
+}
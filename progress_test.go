@@ -0,0 +1,76 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+func TestProgress(t *testing.T) {
+	for name, tt := range map[string]struct {
+		params []any
+		input  string
+		want   []string
+	}{
+		"run": {nil, "1\n2\n3\n4\n5\n", []string{
+			"line 1", "line 2", "progress 2", "line 3", "line 4", "progress 4", "line 5", "progress 5",
+		}},
+		"dry run": {[]any{Preview}, "1\n2\n3\n4\n5\n", []string{
+			"line 1", "line 2", "progress 2", "line 3", "line 4", "progress 4", "line 5", "progress 5",
+		}},
+		"final count reported once": {nil, "1\n2\n3\n4\n", []string{
+			"line 1", "line 2", "progress 2", "line 3", "line 4", "progress 4",
+		}},
+		"nothing executed": {nil, "", []string{"progress 0"}},
+	} {
+		var events []string
+		body := func(args ...any) gloo.Command {
+			events = append(events, fmt.Sprint("line ", args[0]))
+			return echo(args...)
+		}
+		progress := Progress{Every: 2, Func: func(processed int) {
+			events = append(events, fmt.Sprint("progress ", processed))
+		}}
+
+		_, _, err := execute(context.Background(), While(body, append(tt.params, progress)...), tt.input)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !slices.Equal(events, tt.want) {
+			t.Errorf("%s: got %q, want %q", name, events, tt.want)
+		}
+	}
+}
+
+func TestProgressBeforeNextLine(t *testing.T) {
+	body := func(args ...any) gloo.Command { return echo(args...) }
+	reported := make(chan int, 4)
+	progress := Progress{Every: 2, Func: func(processed int) { reported <- processed }}
+
+	r, w := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- While(body, progress).Executor()(context.Background(), r, io.Discard, io.Discard)
+	}()
+
+	// The second command is reported without waiting for a third line
+	io.WriteString(w, "1\n2\n")
+	select {
+	case n := <-reported:
+		if n != 2 {
+			t.Errorf("got progress %d, want 2", n)
+		}
+	case <-time.After(time.Second):
+		t.Error("progress not reported until the next line")
+	}
+
+	w.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,62 @@
+package command
+
+import (
+	"fmt"
+	"time"
+)
+
+// sample is the number of lines read at a point in time
+type sample struct {
+	at    time.Time
+	lines int
+}
+
+// rate tracks throughput over a sliding window for RateReportEvery
+type rate struct {
+	last    time.Time
+	samples []sample
+}
+
+// now returns the current time from Clock
+func (l *loop) now() time.Time {
+	if l.flags.Clock != nil {
+		return l.flags.Clock()
+	}
+	return time.Now()
+}
+
+// reportRate writes the lines per second over the last RateWindow to stderr
+// once RateReportEvery has passed since the previous report
+func (l *loop) reportRate() error {
+	every := time.Duration(l.flags.RateReportEvery)
+	if every <= 0 {
+		return nil
+	}
+
+	now := l.now()
+	if l.rate.last.IsZero() {
+		l.rate.last = now
+		l.rate.samples = []sample{{at: now, lines: l.stats.Lines}}
+		return nil
+	}
+	if now.Sub(l.rate.last) < every {
+		return nil
+	}
+	l.rate.last = now
+
+	window := time.Duration(l.flags.RateWindow)
+	if window < every {
+		window = every
+	}
+
+	// Drop samples older than the window, keeping at least one to measure from
+	l.rate.samples = append(l.rate.samples, sample{at: now, lines: l.stats.Lines})
+	for len(l.rate.samples) > 2 && now.Sub(l.rate.samples[1].at) >= window {
+		l.rate.samples = l.rate.samples[1:]
+	}
+
+	oldest := l.rate.samples[0]
+	perSecond := float64(l.stats.Lines-oldest.lines) / now.Sub(oldest.at).Seconds()
+	_, err := fmt.Fprintf(l.stderr, "while: %.1f lines/s\n", perSecond)
+	return err
+}
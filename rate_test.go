@@ -0,0 +1,52 @@
+package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// fakeClock is a Clock that only moves when advanced
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestReportRate(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+
+	// Lines take a second each, then a quarter of a second from line 5
+	n := 0
+	body := func(args ...any) gloo.Command {
+		n++
+		if n < 5 {
+			clock.Advance(time.Second)
+		} else {
+			clock.Advance(250 * time.Millisecond)
+		}
+		return nil
+	}
+
+	_, stderr, err := execute(context.Background(),
+		While(body, Clock(clock.Now), RateReportEvery(time.Second), RateWindow(2*time.Second)),
+		strings.Repeat("x\n", 13))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reports at 1s to 6s, each over the two seconds before it
+	want := "while: 1.0 lines/s\n" +
+		"while: 1.0 lines/s\n" +
+		"while: 1.0 lines/s\n" +
+		"while: 1.0 lines/s\n" +
+		"while: 2.5 lines/s\n" +
+		"while: 4.0 lines/s\n"
+	if stderr != want {
+		t.Errorf("got %q, want %q", stderr, want)
+	}
+}
@@ -0,0 +1,52 @@
+package command
+
+import (
+	"bufio"
+	"io"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// RecordBody is called by WhileRecords with the raw bytes of each record
+type RecordBody func(record []byte) gloo.Command
+
+// WhileRecords runs body for every record that split cuts from the input,
+// passing its bytes unchanged, so the loop can drive binary formats such as
+// length-prefixed blobs. While and the other variants read their records the
+// same way, with a split function chosen by the record flags, and only then
+// treat them as text.
+//
+// The record flags, including TrimCR, are ignored in favor of split, as are
+// the flags that read or alter text: UTF8Policy, TrimPrefix, TrimSuffix,
+// StartAt, StopAt, SkipEmpty, CommentPrefix, MinLineLen and MaxLineLenFilter.
+// Flags that treat records as opaque, such as Skip, Unique and the limits,
+// still apply.
+func WhileRecords(split bufio.SplitFunc, body RecordBody, parameters ...any) gloo.Command {
+	inputs := gloo.Initialize[string, flags](parameters...)
+	return command{
+		handle: func(lineNum int, line string, args []any) gloo.Command {
+			return body([]byte(line))
+		},
+		records: split,
+		flags:   inputs.Flags,
+	}
+}
+
+// readRecords splits input into records as configured and calls fn with
+// each in turn, numbered from 1, until fn fails or the input ends. ready is
+// called before every record is read, so no record is consumed once it
+// fails, and read errors are attributed to the record being read.
+func (c command) readRecords(input io.Reader, ready func() error, fn func(n int, text string) error) error {
+	scanner := c.scanner(input)
+	for n := 1; ; n++ {
+		if err := ready(); err != nil {
+			return err
+		}
+		if !scanner.Scan() {
+			return readError(n, scanner.Err())
+		}
+		if err := fn(n, scanner.Text()); err != nil {
+			return err
+		}
+	}
+}
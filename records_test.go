@@ -0,0 +1,195 @@
+package command
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// records reads input with readRecords under parameters, collecting every record
+func records(input string, parameters ...any) ([]string, error) {
+	c := command{flags: gloo.Initialize[string, flags](parameters...).Flags}
+	var got []string
+	err := c.readRecords(strings.NewReader(input), func() error { return nil }, func(n int, text string) error {
+		if n != len(got)+1 {
+			return errors.New("records out of sequence")
+		}
+		got = append(got, text)
+		return nil
+	})
+	return got, err
+}
+
+func TestReadRecords(t *testing.T) {
+	for name, tt := range map[string]struct {
+		input  string
+		params []any
+		want   []string
+	}{
+		"lines":            {"a\r\nb\n\nc", nil, []string{"a", "b", "", "c"}},
+		"nul":              {"a\x00b\nc\x00", []any{NulDelimited}, []string{"a", "b\nc"}},
+		"delimiter":        {"a--b----c", []any{RecordDelimiter("--")}, []string{"a", "b", "", "c"}},
+		"regexp":           {"a;;b;c", []any{RecordSeparatorRegex{regexp.MustCompile(`;+`)}}, []string{"a", "b", "c"}},
+		"fixed":            {"abcdefg", []any{FixedRecordLength(3)}, []string{"abc", "def", "g"}},
+		"fixed nul padded": {"ab\x00cd\x00", []any{FixedRecordLength(3), TrimNulls}, []string{"ab", "cd"}},
+		"trim cr":          {"a\r\x00b\x00", []any{NulDelimited, StripCR}, []string{"a", "b"}},
+	} {
+		got, err := records(tt.input, tt.params...)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%s: got %q, want %q", name, got, tt.want)
+		}
+	}
+}
+
+func TestReadRecordsError(t *testing.T) {
+	_, err := records("ab\nabcdef\n", MaxLineSize(4))
+	if !errors.Is(err, bufio.ErrTooLong) || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("got %v, want bufio.ErrTooLong at line 2", err)
+	}
+}
+
+func TestReadRecordsReadyFirst(t *testing.T) {
+	c := command{}
+	stop := errors.New("stop")
+	calls := 0
+	ready := func() error {
+		if calls++; calls > 2 {
+			return stop
+		}
+		return nil
+	}
+
+	var got []string
+	err := c.readRecords(strings.NewReader("a\nb\nc\n"), ready, func(n int, text string) error {
+		got = append(got, text)
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("got %v, want the error from ready", err)
+	}
+	if strings.Join(got, "|") != "a|b" {
+		t.Errorf("got %q, want [a b]", got)
+	}
+}
+
+func TestWhileRecordsRaw(t *testing.T) {
+	body := func(record []byte) gloo.Command { return echo(fmt.Sprintf("%q", record)) }
+	split := splitDelimiter([]byte{0})
+
+	out, _, err := execute(context.Background(), WhileRecords(split, body,
+		UTF8Replace, TrimPrefix("#"), SkipBlank, CommentPrefix("#"), StripCR, MinLineLen(3), Skip(1),
+	), "skipped\x00ab\xff\x00\x00# x\r\x00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"ab\xff"` + "\n" + `""` + "\n" + `"# x\r"` + "\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestSplitRegexpAcrossReads(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, input string
+		want           []string
+	}{
+		{`-+`, "a---b-c", []string{"a", "b", "c"}},
+		{`-+`, "a---", []string{"a"}},
+		{`-+`, "---a", []string{"", "a"}},
+		{`\n=+\n`, "a\nb\n===\nc\n=\n", []string{"a\nb", "c"}},
+		{`x*`, "abc", []string{"abc"}},
+	} {
+		c := command{flags: gloo.Initialize[string, flags](RecordSeparatorRegex{regexp.MustCompile(tt.pattern)}).Flags}
+
+		// Reading a byte at a time makes every match touch the end of the buffer
+		for name, input := range map[string]io.Reader{
+			"whole":    strings.NewReader(tt.input),
+			"one byte": iotest.OneByteReader(strings.NewReader(tt.input)),
+		} {
+			var got []string
+			err := c.readRecords(input, func() error { return nil }, func(n int, text string) error {
+				got = append(got, text)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("%s on %q, %s: got %q, want %q", tt.pattern, tt.input, name, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestSplitFixed(t *testing.T) {
+	input := "ab\x00\x00cdef\x00\x00\x00\x00g\x00"
+
+	for _, tt := range []struct {
+		trim TrimNullPadding
+		want []string
+	}{
+		{KeepNullPadding, []string{"ab\x00\x00", "cdef", "\x00\x00\x00\x00", "g\x00"}},
+		{TrimNulls, []string{"ab", "cdef", "", "g"}},
+	} {
+		c := command{flags: gloo.Initialize[string, flags](FixedRecordLength(4), tt.trim).Flags}
+
+		for name, input := range map[string]io.Reader{
+			"whole":    strings.NewReader(input),
+			"one byte": iotest.OneByteReader(strings.NewReader(input)),
+		} {
+			var got []string
+			err := c.readRecords(input, func() error { return nil }, func(n int, text string) error {
+				got = append(got, text)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("trim %v, %s: got %q, want %q", tt.trim, name, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestTrimCRMixedEndings(t *testing.T) {
+	for name, tt := range map[string]struct {
+		input  string
+		params []any
+		want   []string
+	}{
+		"lines":     {"a\r\nb\nc\r\r\n\r\nd\r", nil, []string{"a", "b", "c\r", "", "d"}},
+		"delimiter": {"a\r;b;c\r\r;\r;d", []any{RecordDelimiter(";"), StripCR}, []string{"a", "b", "c\r", "", "d"}},
+		"kept":      {"a\r;b;c\r\r;\r;d", []any{RecordDelimiter(";")}, []string{"a\r", "b", "c\r\r", "\r", "d"}},
+	} {
+		c := command{flags: gloo.Initialize[string, flags](tt.params...).Flags}
+
+		for reader, input := range map[string]io.Reader{
+			"whole":    strings.NewReader(tt.input),
+			"one byte": iotest.OneByteReader(strings.NewReader(tt.input)),
+		} {
+			var got []string
+			err := c.readRecords(input, func() error { return nil }, func(n int, text string) error {
+				got = append(got, text)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("%s, %s: got %q, want %q", name, reader, got, tt.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package command
+
+import "context"
+
+// resourceKey is the context key under which ResourceLimit's semaphore is stored
+type resourceKey struct{}
+
+// withResources stores a semaphore of n slots in ctx for AcquireResource
+func withResources(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, resourceKey{}, make(chan struct{}, n))
+}
+
+// AcquireResource takes one of the slots configured with ResourceLimit,
+// blocking until one is free or ctx is done. Commands call it with the
+// context they were executed with before opening a file, connection or
+// similar, and call ReleaseResource once done. The cap is cooperative: it
+// only holds back commands that ask. Without ResourceLimit it never blocks.
+func AcquireResource(ctx context.Context) error {
+	slots, ok := ctx.Value(resourceKey{}).(chan struct{})
+	if !ok {
+		return nil
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ReleaseResource returns a slot taken by AcquireResource. It panics if no
+// slot is held, as a release without a matching acquire would otherwise
+// block forever.
+func ReleaseResource(ctx context.Context) {
+	slots, ok := ctx.Value(resourceKey{}).(chan struct{})
+	if !ok {
+		return
+	}
+
+	select {
+	case <-slots:
+	default:
+		panic("while: ReleaseResource without AcquireResource")
+	}
+}
@@ -0,0 +1,59 @@
+package command
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+func TestResourceLimit(t *testing.T) {
+	var held, peak atomic.Int32
+	body := func(args ...any) gloo.Command {
+		return gloo.RawCommand(func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+			if err := AcquireResource(ctx); err != nil {
+				return err
+			}
+			defer ReleaseResource(ctx)
+
+			n := held.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			held.Add(-1)
+			return nil
+		})
+	}
+
+	_, _, err := execute(context.Background(), While(body, Parallelism(8), ResourceLimit(2)), "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := peak.Load(); p > 2 {
+		t.Errorf("%d resources held at once, want at most 2", p)
+	}
+}
+
+func TestReleaseResourceWithoutAcquire(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic releasing an unheld resource")
+		}
+	}()
+	ReleaseResource(withResources(context.Background(), 1))
+}
+
+func TestResourcesWithoutLimit(t *testing.T) {
+	ctx := context.Background()
+	if err := AcquireResource(ctx); err != nil {
+		t.Fatal(err)
+	}
+	ReleaseResource(ctx)
+}
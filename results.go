@@ -0,0 +1,69 @@
+package command
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// lineResult is the ResultWriter record for a single executed line
+type lineResult struct {
+	Line       int    `json:"line"`
+	Input      string `json:"input"`
+	OK         bool   `json:"ok"`
+	Error      string `json:"error,omitempty"`
+	DurationUS int64  `json:"duration_us"`
+}
+
+// recordResult writes the ResultWriter record for a finished line
+func (l *loop) recordResult(r result) error {
+	w := l.flags.ResultWriter.Writer
+	if w == nil {
+		return nil
+	}
+
+	record := lineResult{
+		Line:       r.lineNum,
+		Input:      r.line,
+		OK:         r.err == nil,
+		DurationUS: r.elapsed.Microseconds(),
+	}
+	if r.err != nil {
+		record.Error = r.err.Error()
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	if l.flags.ResultJSONArray {
+		separator := ",\n"
+		if !l.resultsStarted {
+			separator = "[\n"
+		}
+		if _, err := io.WriteString(w, separator); err != nil {
+			return err
+		}
+	} else {
+		data = append(data, '\n')
+	}
+	l.resultsStarted = true
+
+	_, err = w.Write(data)
+	return err
+}
+
+// closeResults terminates the ResultJSONArray array,
+// so that it is valid JSON even if the loop failed
+func (l *loop) closeResults() error {
+	w := l.flags.ResultWriter.Writer
+	if w == nil || !l.flags.ResultJSONArray {
+		return nil
+	}
+
+	closing := "\n]\n"
+	if !l.resultsStarted {
+		closing = "[]\n"
+	}
+	_, err := io.WriteString(w, closing)
+	return err
+}
@@ -0,0 +1,49 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+func TestResultJSONArray(t *testing.T) {
+	body := func(args ...any) gloo.Command {
+		if args[0] == "b" {
+			return fails(errors.New("failed"))
+		}
+		return echo(args...)
+	}
+
+	for name, tt := range map[string]struct {
+		input string
+		want  []lineResult
+	}{
+		"empty": {"", []lineResult{}},
+		"lines": {"a\nb\nc\n", []lineResult{
+			{Line: 1, Input: "a", OK: true},
+			{Line: 2, Input: "b", Error: "failed"},
+			{Line: 3, Input: "c", OK: true},
+		}},
+	} {
+		var buf bytes.Buffer
+		execute(context.Background(), While(body, ResultWriter{&buf}, ResultArray, KeepGoing), tt.input)
+
+		var got []lineResult
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("%s: %v in %q", name, err, buf.String())
+		}
+		if got == nil || len(got) != len(tt.want) {
+			t.Fatalf("%s: got %+v, want %+v", name, got, tt.want)
+		}
+		for i := range got {
+			got[i].DurationUS = 0
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: result %d = %+v, want %+v", name, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package command
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// retry runs cmd, running it again up to Retries times while it fails.
+// Output captured from a failed attempt is discarded.
+func (l *loop) retry(cmd gloo.Command, stdout io.Writer, lineNum int) error {
+	err := l.exec(cmd, stdout, lineNum)
+	for attempt := 0; err != nil && !control(err) && attempt < int(l.flags.Retries); attempt++ {
+		if !l.sleep(l.retryDelay(attempt)) {
+			return err
+		}
+		if buf, ok := stdout.(*bytes.Buffer); ok {
+			buf.Reset()
+		}
+		err = l.exec(cmd, stdout, lineNum)
+	}
+	return err
+}
+
+// sleep waits for d, reporting false if the context was cancelled first
+func (l *loop) sleep(d time.Duration) bool {
+	if d <= 0 {
+		return l.ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-l.ctx.Done():
+		return false
+	}
+}
+
+// retryDelay returns RetryDelay grown by RetryBackoff for each earlier
+// attempt, randomly varied by up to RetryJitter of its length in either direction
+func (l *loop) retryDelay(attempt int) time.Duration {
+	delay := time.Duration(l.flags.RetryDelay)
+	if l.flags.RetryBackoff > 1 {
+		delay = time.Duration(float64(delay) * math.Pow(float64(l.flags.RetryBackoff), float64(attempt)))
+	}
+	if l.flags.RetryJitter <= 0 {
+		return delay
+	}
+	return jitter(delay, float64(l.flags.RetryJitter), l.random.Float64())
+}
+
+// jitter varies d by up to fraction of its length, where r in [0, 1)
+// picks the point between -fraction and +fraction
+func jitter(d time.Duration, fraction, r float64) time.Duration {
+	return d + time.Duration(float64(d)*fraction*(2*r-1))
+}
+
+// random is a source of randomness safe for use by parallel commands
+type random struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func newRandom(seed int64) *random {
+	if seed == 0 {
+		return &random{rng: rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))}
+	}
+	return &random{rng: rand.New(rand.NewPCG(uint64(seed), uint64(seed)))}
+}
+
+// Float64 returns a number in [0, 1)
+func (r *random) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rng.Float64()
+}
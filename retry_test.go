@@ -0,0 +1,122 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// flaky returns a body whose command fails its first failures attempts,
+// writing partial output each time, then succeeds. It counts every attempt.
+func flaky(failures int, attempts *int) Body {
+	return func(args ...any) gloo.Command {
+		return gloo.RawCommand(func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+			*attempts++
+			if *attempts <= failures {
+				fmt.Fprintln(stdout, "partial")
+				return errors.New("unavailable")
+			}
+			_, err := fmt.Fprintln(stdout, args...)
+			return err
+		})
+	}
+}
+
+func TestRetrySucceedsAfterFailures(t *testing.T) {
+	attempts := 0
+	out, _, err := execute(context.Background(), While(flaky(2, &attempts), Retries(2), RetryDelay(time.Millisecond)), "a\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "a\n" {
+		t.Errorf("got %q, want only the successful attempt's output", out)
+	}
+	if attempts != 3 {
+		t.Errorf("got %d attempts, want 3", attempts)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	attempts := 0
+	_, _, err := execute(context.Background(), While(flaky(2, &attempts), Retries(1)), "a\n")
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) || lineErr.Line != 1 {
+		t.Fatalf("got %v, want a failure of line 1", err)
+	}
+	if attempts != 2 {
+		t.Errorf("got %d attempts, want 2", attempts)
+	}
+}
+
+func TestRetryStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	attempts := 0
+	start := time.Now()
+	_, _, err := execute(ctx, While(flaky(2, &attempts), Retries(2), RetryDelay(time.Minute)), "a\n")
+	if err == nil {
+		t.Error("got nil, want the failure of line 1")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("retry delay not cut short, took %s", elapsed)
+	}
+	if attempts != 1 {
+		t.Errorf("got %d attempts, want 1", attempts)
+	}
+}
+
+func TestJitterBounds(t *testing.T) {
+	const d = time.Second
+	for _, tt := range []struct {
+		r    float64
+		want time.Duration
+	}{
+		{0, 750 * time.Millisecond},
+		{0.5, d},
+		{0.75, 1125 * time.Millisecond},
+	} {
+		if got := jitter(d, 0.25, tt.r); got != tt.want {
+			t.Errorf("jitter(%v, 0.25, %v) = %v, want %v", d, tt.r, got, tt.want)
+		}
+	}
+	if got := jitter(d, 0.25, math.Nextafter(1, 0)); got > 1250*time.Millisecond {
+		t.Errorf("jitter near r=1 = %v, want at most 1.25s", got)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	delays := func(params ...any) []time.Duration {
+		c := While(nil, params...).(command)
+		l := c.newLoop(context.Background(), io.Discard, io.Discard)
+		var ds []time.Duration
+		for attempt := range 4 {
+			ds = append(ds, l.retryDelay(attempt))
+		}
+		return ds
+	}
+
+	base := []any{RetryDelay(100 * time.Millisecond), RetryBackoff(2)}
+	if got, want := delays(base...), []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	jittered := append(base, RetryJitter(0.5), RetrySeed(7))
+	first := delays(jittered...)
+	for attempt, d := range first {
+		exact := 100 * time.Millisecond << attempt
+		if d < exact/2 || d > exact*3/2 {
+			t.Errorf("attempt %d: delay %v outside %v±50%%", attempt, d, exact)
+		}
+	}
+	if again := delays(jittered...); !slices.Equal(first, again) {
+		t.Errorf("RetrySeed gave %v then %v, want the same delays", first, again)
+	}
+}
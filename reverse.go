@@ -0,0 +1,46 @@
+package command
+
+import "io"
+
+// runReversed reads all of input into memory and processes the lines from
+// last to first. The whole input is held at once, counting against
+// MaxBufferedBytes and LookbehindLines. Skip and StartAt drop lines as they
+// are read, so they apply to the start of the input rather than its end.
+func (l *loop) runReversed(input io.Reader) error {
+	var (
+		lines []string
+		size  int
+	)
+
+	err := l.readRecords(input, l.ctx.Err, func(n int, line string) error {
+		if l.skip(n) {
+			return nil
+		}
+		if l.before(l.trim(line)) {
+			l.discard()
+			return nil
+		}
+		size += len(line)
+		if limit := int(l.flags.MaxBufferedBytes); limit > 0 && size > limit {
+			return ErrBufferLimit
+		}
+		if err := l.lookbehind(len(lines) + 1); err != nil {
+			return err
+		}
+		lines = append(lines, line)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := len(lines) - 1; i >= 0; i-- {
+		if err := l.ready(); err != nil {
+			return err
+		}
+		if err := l.next(lines[i]); err != nil {
+			return err
+		}
+	}
+	return l.endSample()
+}
@@ -0,0 +1,33 @@
+package command
+
+import (
+	"context"
+	"testing"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+func TestReverse(t *testing.T) {
+	body := func(args ...any) gloo.Command { return echo(args...) }
+	start := StartAt(func(line string) bool { return line == "BEGIN" })
+
+	for name, tt := range map[string]struct {
+		params []any
+		want   string
+	}{
+		"all":           {nil, "c\nb\nBEGIN\nHEADER\n"},
+		"skip":          {[]any{Skip(1)}, "c\nb\nBEGIN\n"},
+		"start":         {[]any{start}, "c\nb\nBEGIN\n"},
+		"exclude start": {[]any{start, ExcludeStartLine}, "c\nb\n"},
+		"lookbehind":    {[]any{Skip(1), LookbehindLines(3)}, "c\nb\nBEGIN\n"},
+		"dropped":       {[]any{start, LookbehindLines(3)}, "c\nb\nBEGIN\n"},
+	} {
+		out, _, err := execute(context.Background(), While(body, append(tt.params, Backward)...), "HEADER\nBEGIN\nb\nc\n")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if out != tt.want {
+			t.Errorf("%s: got %q, want %q", name, out, tt.want)
+		}
+	}
+}
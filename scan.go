@@ -0,0 +1,143 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// scanner returns a scanner splitting input into records as configured
+func (c command) scanner(input io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(input)
+	scanner.Split(c.splitFunc())
+	if size := c.maxRecordSize(); size != bufio.MaxScanTokenSize {
+		scanner.Buffer(make([]byte, 0, min(size, 4096)), size)
+	}
+	return scanner
+}
+
+// splitFunc returns the function cutting input into records: the one given
+// to WhileRecords, passed on unchanged, or else one chosen by the record
+// flags. Every variant reads its records through it.
+func (c command) splitFunc() bufio.SplitFunc {
+	if c.records != nil {
+		return c.records
+	}
+
+	var split bufio.SplitFunc
+	if c.flags.NullDelimited {
+		split = splitDelimiter([]byte{0})
+	}
+	if delim := c.flags.RecordDelimiter; delim != "" {
+		split = splitDelimiter([]byte(delim))
+	}
+	if re := c.flags.RecordSeparatorRegex.Regexp; re != nil {
+		split = splitRegexp(re)
+	}
+	if n := int(c.flags.FixedRecordLength); n > 0 {
+		split = splitFixed(n, bool(c.flags.TrimNullPadding))
+	}
+	if split == nil {
+		// Lines already drop the carriage return of a CRLF ending
+		return bufio.ScanLines
+	}
+	if c.flags.TrimCR {
+		split = trimCR(split)
+	}
+	return split
+}
+
+// readError attributes a scanner error to the line being read, suggesting
+// MaxLineSize when the line was too long
+func readError(lineNum int, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, bufio.ErrTooLong) {
+		return fmt.Errorf("while: read error at line %d: %w (raise MaxLineSize)", lineNum, err)
+	}
+	return fmt.Errorf("while: read error at line %d: %w", lineNum, err)
+}
+
+// maxRecordSize returns the longest record the scanner accepts: MaxLineSize
+// or the bufio default, raised to fit FixedRecordLength
+func (c command) maxRecordSize() int {
+	size := bufio.MaxScanTokenSize
+	if c.flags.MaxLineSize > 0 {
+		size = int(c.flags.MaxLineSize)
+	}
+	return max(size, int(c.flags.FixedRecordLength))
+}
+
+// trimCR wraps split to strip a single trailing carriage return from records
+func trimCR(split bufio.SplitFunc) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		advance, token, err = split(data, atEOF)
+		return advance, bytes.TrimSuffix(token, []byte("\r")), err
+	}
+}
+
+// splitDelimiter returns a split function ending records at delim,
+// which is stripped. A final record without delim is still returned.
+func splitDelimiter(delim []byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if i := bytes.Index(data, delim); i >= 0 {
+			return i + len(delim), data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+// splitRegexp returns a split function ending records at matches of re,
+// which are stripped. A match touching the end of the buffered data is only
+// accepted at EOF, since more input could extend it.
+func splitRegexp(re *regexp.Regexp) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		for start := 0; start < len(data); {
+			loc := re.FindIndex(data[start:])
+			if loc == nil {
+				break
+			}
+			begin, end := start+loc[0], start+loc[1]
+			if begin == end {
+				// Ignore empty matches
+				start = end + 1
+				continue
+			}
+			if end == len(data) && !atEOF {
+				break
+			}
+			return end, data[:begin], nil
+		}
+
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+// splitFixed returns a split function cutting records of exactly n bytes,
+// optionally stripping trailing NUL padding. A short final record is
+// returned as it is rather than dropped.
+func splitFixed(n int, trimNul bool) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if len(data) >= n {
+			advance, token = n, data[:n]
+		} else if atEOF && len(data) > 0 {
+			advance, token = len(data), data
+		} else {
+			return 0, nil, nil
+		}
+		if trimNul {
+			token = bytes.TrimRight(token, "\x00")
+		}
+		return advance, token, nil
+	}
+}
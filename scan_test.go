@@ -0,0 +1,30 @@
+package command
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+func TestMaxLineSize(t *testing.T) {
+	long := strings.Repeat("x", 100<<10)
+	input := "short\n" + long + "\n"
+	body := func(args ...any) gloo.Command { return echo(len(args[0].(string))) }
+
+	_, _, err := execute(context.Background(), While(body), input)
+	if !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("default buffer: got %v, want bufio.ErrTooLong", err)
+	}
+
+	out, _, err := execute(context.Background(), While(body, MaxLineSize(1<<20)), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "5\n102400\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
@@ -0,0 +1,30 @@
+package command
+
+// before reports whether line comes before the StartAt line and so is
+// skipped. The StartAt line itself is kept unless ExcludeStart is set.
+func (l *loop) before(line string) bool {
+	if l.flags.StartAt == nil || l.started {
+		return false
+	}
+	if !l.flags.StartAt(line) {
+		return true
+	}
+	l.started = true
+	return bool(l.flags.ExcludeStart)
+}
+
+// after reports whether line is the StopAt line and so ends the loop,
+// skipped if ExcludeStop is set or else processed as the last line.
+// Lines are only checked once any StartAt line has been seen.
+func (l *loop) after(line string) (bool, error) {
+	if l.flags.StopAt == nil || l.flags.StartAt != nil && !l.started || !l.flags.StopAt(line) {
+		return false, nil
+	}
+	if l.flags.ExcludeStop {
+		return true, l.stop(StopAtLine)
+	}
+	// Set now in case this is the last line of input
+	l.ending = true
+	l.stopReason = StopAtLine
+	return false, nil
+}
@@ -0,0 +1,43 @@
+package command
+
+import (
+	"context"
+	"testing"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+func TestSection(t *testing.T) {
+	body := func(args ...any) gloo.Command { return echo(args...) }
+	start := StartAt(func(line string) bool { return line == "BEGIN" })
+	stop := StopAt(func(line string) bool { return line == "END" })
+
+	for name, tt := range map[string]struct {
+		input  string
+		params []any
+		want   string
+		reason StopReason
+	}{
+		"include":       {"END\nBEGIN\na\nEND\nb\n", nil, "BEGIN\na\nEND\n", StopAtLine},
+		"exclude":       {"END\nBEGIN\na\nEND\nb\n", []any{ExcludeStartLine, ExcludeStopLine}, "a\n", StopAtLine},
+		"stop last":     {"BEGIN\na\nEND\n", nil, "BEGIN\na\nEND\n", StopAtLine},
+		"exclude last":  {"BEGIN\na\nEND\n", []any{ExcludeStopLine}, "BEGIN\na\n", StopAtLine},
+		"never started": {"a\nEND\n", nil, "", StopEOF},
+	} {
+		var (
+			stats  Stats
+			onStop StopReason
+		)
+		params := append(tt.params, start, stop, &stats, OnStop(func(r StopReason) { onStop = r }))
+		out, _, err := execute(context.Background(), While(body, params...), tt.input)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if out != tt.want {
+			t.Errorf("%s: got %q, want %q", name, out, tt.want)
+		}
+		if stats.StopReason != tt.reason || onStop != tt.reason {
+			t.Errorf("%s: stopped by %s, OnStop got %s; want %s", name, stats.StopReason, onStop, tt.reason)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package command
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"io"
+	"os"
+	"slices"
+)
+
+// defaultSortRunBytes is the size of the sorted runs ExternalSort spills
+// to disk when MaxBufferedBytes is not set
+const defaultSortRunBytes = 64 << 20
+
+// runSorted reads all of input, sorting it in runs that are spilled to
+// temporary files and then merged, and processes the lines in sorted order.
+// The temporary files are removed when it returns.
+func (l *loop) runSorted(input io.Reader) error {
+	runBytes := int(l.flags.MaxBufferedBytes)
+	if runBytes <= 0 {
+		runBytes = defaultSortRunBytes
+	}
+
+	var (
+		runs  []*os.File
+		lines []string
+		size  int
+	)
+	defer func() {
+		for _, run := range runs {
+			run.Close()
+			os.Remove(run.Name())
+		}
+	}()
+
+	err := l.readRecords(input, l.ctx.Err, func(n int, line string) error {
+		if l.skip(n) {
+			return nil
+		}
+		lines = append(lines, line)
+		size += len(line)
+		if size < runBytes {
+			return nil
+		}
+
+		run, err := l.spill(lines)
+		if run != nil {
+			runs = append(runs, run)
+		}
+		lines, size = nil, 0
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// Everything fit in memory
+	if len(runs) == 0 {
+		slices.Sort(lines)
+		for _, line := range lines {
+			if err := l.ready(); err != nil {
+				return err
+			}
+			if err := l.next(line); err != nil {
+				return err
+			}
+		}
+		return l.endSample()
+	}
+
+	if len(lines) > 0 {
+		run, err := l.spill(lines)
+		if run != nil {
+			runs = append(runs, run)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return l.merge(runs)
+}
+
+// spill sorts lines and writes them to a new temporary file in TempDir,
+// each prefixed by its length so lines may contain newlines
+func (l *loop) spill(lines []string) (*os.File, error) {
+	slices.Sort(lines)
+
+	run, err := os.CreateTemp(string(l.flags.TempDir), "while-sort-*")
+	if err != nil {
+		return nil, err
+	}
+
+	w := bufio.NewWriter(run)
+	for _, line := range lines {
+		w.Write(binary.AppendUvarint(nil, uint64(len(line))))
+		w.WriteString(line)
+	}
+	if err := w.Flush(); err != nil {
+		return run, err
+	}
+	_, err = run.Seek(0, io.SeekStart)
+	return run, err
+}
+
+// merge processes the lines of the sorted runs in overall sorted order
+func (l *loop) merge(runs []*os.File) error {
+	h := make(runHeap, 0, len(runs))
+	for _, run := range runs {
+		r := &runReader{r: bufio.NewReader(run)}
+		ok, err := r.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			h = append(h, r)
+		}
+	}
+	heap.Init(&h)
+
+	for h.Len() > 0 {
+		r := h[0]
+		if err := l.ready(); err != nil {
+			return err
+		}
+		if err := l.next(r.line); err != nil {
+			return err
+		}
+
+		ok, err := r.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+	return l.endSample()
+}
+
+// runReader reads back the lines of a spilled run
+type runReader struct {
+	r    *bufio.Reader
+	line string
+}
+
+// next reads the following line, reporting false at the end of the run
+func (r *runReader) next() (bool, error) {
+	n, err := binary.ReadUvarint(r.r)
+	if err == io.EOF {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r.r, buf); err != nil {
+		return false, err
+	}
+	r.line = string(buf)
+	return true, nil
+}
+
+// runHeap orders runs by their current line
+type runHeap []*runReader
+
+func (h runHeap) Len() int           { return len(h) }
+func (h runHeap) Less(i, j int) bool { return h[i].line < h[j].line }
+func (h runHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *runHeap) Push(x any)        { *h = append(*h, x.(*runReader)) }
+
+func (h *runHeap) Pop() any {
+	old := *h
+	r := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return r
+}
@@ -0,0 +1,60 @@
+package command
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+func TestExternalSortSpills(t *testing.T) {
+	body := func(args ...any) gloo.Command { return echo(args...) }
+	dir := t.TempDir()
+
+	// Runs of two lines each are spilled and merged
+	out, _, err := execute(context.Background(), While(body, SortedOrder, MaxBufferedBytes(2), TempDir(dir)), "e\nb\ng\na\nd\nf\nc\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a\nb\nc\nd\ne\nf\ng\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+	assertEmptyDir(t, dir)
+}
+
+func TestExternalSortRemovesRunsOnCancel(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls int
+	body := func(args ...any) gloo.Command {
+		calls++
+		if calls == 2 {
+			cancel()
+		}
+		return echo(args...)
+	}
+
+	out, _, err := execute(ctx, While(body, SortedOrder, MaxBufferedBytes(2), TempDir(dir)), "d\nc\nb\na\n")
+	if err == nil {
+		t.Fatal("got nil error, want cancellation")
+	}
+	if want := "a\nb\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+	assertEmptyDir(t, dir)
+}
+
+// assertEmptyDir fails the test if dir holds any files
+func assertEmptyDir(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		t.Errorf("left behind %s", e.Name())
+	}
+}
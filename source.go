@@ -0,0 +1,59 @@
+package command
+
+import (
+	"context"
+	"io"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// Source yields input lines one at a time.
+// It returns ok=false once there are no more lines.
+type Source func(ctx context.Context) (line string, ok bool, err error)
+
+type sourceCommand struct {
+	command
+	next Source
+}
+
+// WhileSource runs body for every line yielded by next instead of reading stdin.
+// Context cancellation is checked before each call to next, and any error
+// returned by next stops the loop.
+func WhileSource(next Source, body Body, parameters ...any) gloo.Command {
+	inputs := gloo.Initialize[string, flags](parameters...)
+	return sourceCommand{
+		command: command{body: body, flags: inputs.Flags},
+		next:    next,
+	}
+}
+
+func (c sourceCommand) Executor() gloo.CommandExecutor {
+	return func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+		l := c.newLoop(ctx, stdout, stderr)
+		return l.finish(stopped(c.run(l)))
+	}
+}
+
+// run feeds lines from next through the loop until it is exhausted
+func (c sourceCommand) run(l *loop) error {
+	for n := 1; ; n++ {
+		if err := l.ready(); err != nil {
+			return err
+		}
+
+		line, ok, err := c.next(l.ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return l.endSample()
+		}
+		if l.skip(n) {
+			continue
+		}
+
+		if err := l.next(line); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// slice returns a Source yielding lines in turn
+func slice(lines ...string) Source {
+	return func(ctx context.Context) (string, bool, error) {
+		if len(lines) == 0 {
+			return "", false, nil
+		}
+		line := lines[0]
+		lines = lines[1:]
+		return line, true, nil
+	}
+}
+
+func TestWhileSource(t *testing.T) {
+	body := func(args ...any) gloo.Command { return echo(args...) }
+
+	out, _, err := execute(context.Background(), WhileSource(slice("a", "b", "c"), body), "ignored\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a\nb\nc\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestWhileSourceError(t *testing.T) {
+	body := func(args ...any) gloo.Command { return echo(args...) }
+	failed := errors.New("source failed")
+	lines := slice("a", "b")
+	next := func(ctx context.Context) (string, bool, error) {
+		line, ok, err := lines(ctx)
+		if !ok {
+			return "", false, failed
+		}
+		return line, ok, err
+	}
+
+	out, _, err := execute(context.Background(), WhileSource(next, body), "")
+	if !errors.Is(err, failed) {
+		t.Errorf("got %v, want the source's error", err)
+	}
+	if want := "a\nb\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestWhileSourceCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	next := func(ctx context.Context) (string, bool, error) {
+		calls++
+		return "line", true, nil
+	}
+	body := func(args ...any) gloo.Command {
+		if calls == 3 {
+			cancel()
+		}
+		return echo(args...)
+	}
+
+	_, _, err := execute(ctx, WhileSource(next, body), "")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	if calls != 3 {
+		t.Errorf("next called %d times, want 3", calls)
+	}
+}
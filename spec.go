@@ -0,0 +1,107 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+// LoopSpec describes a shell while loop in either of its two forms, as a
+// single entry point for code translated from shell:
+//
+//	while read a b; do ...; done   // Read and Each
+//	while cmd; do ...; done        // Condition and Body
+type LoopSpec struct {
+	Read []string                                  // Variables assigned from each line
+	Each func(vars map[string]string) gloo.Command // Body run for each line
+
+	Condition gloo.Command // Loop continues while this succeeds
+	Body      gloo.Command // Run after each successful Condition
+
+	Parameters []any // Flags passed to the loop; read form only
+}
+
+// ErrInvalidSpec is returned by FromSpec for a LoopSpec that does not
+// describe exactly one loop form
+var ErrInvalidSpec = errors.New("while: invalid loop spec")
+
+// FromSpec builds the loop described by spec
+func FromSpec(spec LoopSpec) (gloo.Command, error) {
+	reads := len(spec.Read) > 0 || spec.Each != nil
+	conditional := spec.Condition != nil || spec.Body != nil
+
+	switch {
+	case reads && conditional:
+		return nil, fmt.Errorf("%w: both read and condition forms given", ErrInvalidSpec)
+	case reads:
+		if len(spec.Read) == 0 || spec.Each == nil {
+			return nil, fmt.Errorf("%w: read form needs Read and Each", ErrInvalidSpec)
+		}
+		return While(readBody(spec.Read, spec.Each), spec.Parameters...), nil
+	case conditional:
+		if spec.Condition == nil || spec.Body == nil {
+			return nil, fmt.Errorf("%w: condition form needs Condition and Body", ErrInvalidSpec)
+		}
+		if len(spec.Parameters) > 0 {
+			return nil, fmt.Errorf("%w: condition form takes no Parameters", ErrInvalidSpec)
+		}
+		return WhileCommand(spec.Condition, spec.Body), nil
+	default:
+		return nil, fmt.Errorf("%w: no loop form given", ErrInvalidSpec)
+	}
+}
+
+// readBody assigns fields to names like shell read: one field per name,
+// with the last name taking all remaining fields
+func readBody(names []string, each func(vars map[string]string) gloo.Command) Body {
+	return func(args ...any) gloo.Command {
+		vars := make(map[string]string, len(names))
+		for i, name := range names {
+			switch {
+			case i >= len(args):
+				vars[name] = ""
+			case i == len(names)-1:
+				rest := make([]string, 0, len(args)-i)
+				for _, arg := range args[i:] {
+					rest = append(rest, fmt.Sprint(arg))
+				}
+				vars[name] = strings.Join(rest, " ")
+			default:
+				vars[name] = fmt.Sprint(args[i])
+			}
+		}
+		return each(vars)
+	}
+}
+
+type conditionCommand struct {
+	condition gloo.Command
+	body      gloo.Command
+}
+
+// WhileCommand runs body for as long as condition succeeds,
+// like the shell's `while cmd; do ...; done`
+func WhileCommand(condition, body gloo.Command) gloo.Command {
+	return conditionCommand{condition: condition, body: body}
+}
+
+func (c conditionCommand) Executor() gloo.CommandExecutor {
+	return func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := c.condition.Executor()(ctx, stdin, stdout, stderr); err != nil {
+				// A failing condition ends the loop
+				return nil
+			}
+			if err := c.body.Executor()(ctx, stdin, stdout, stderr); err != nil {
+				return err
+			}
+		}
+	}
+}
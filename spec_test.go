@@ -0,0 +1,73 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+func TestFromSpecRead(t *testing.T) {
+	cmd, err := FromSpec(LoopSpec{
+		Read: []string{"name", "rest"},
+		Each: func(vars map[string]string) gloo.Command {
+			return echo(vars["rest"] + "|" + vars["name"])
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, _, err := execute(context.Background(), cmd, "a b c\nd\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "b c|a\n|d\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestFromSpecCondition(t *testing.T) {
+	n := 0
+	cmd, err := FromSpec(LoopSpec{
+		Condition: gloo.RawCommand(func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+			if n == 3 {
+				return errors.New("done")
+			}
+			return nil
+		}),
+		Body: gloo.RawCommand(func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+			n++
+			return nil
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := execute(context.Background(), cmd, ""); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("body ran %d times, want 3", n)
+	}
+}
+
+func TestFromSpecInvalid(t *testing.T) {
+	each := func(vars map[string]string) gloo.Command { return nil }
+	cond := echo()
+
+	for name, spec := range map[string]LoopSpec{
+		"empty":                  {},
+		"both forms":             {Read: []string{"a"}, Each: each, Condition: cond, Body: cond},
+		"read without each":      {Read: []string{"a"}},
+		"condition without body": {Condition: cond},
+		"condition with flags":   {Condition: cond, Body: cond, Parameters: []any{MaxIterations(2)}},
+	} {
+		if _, err := FromSpec(spec); !errors.Is(err, ErrInvalidSpec) {
+			t.Errorf("%s: got %v, want ErrInvalidSpec", name, err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// spinEvery is how often the Spinner is redrawn
+const spinEvery = 100 * time.Millisecond
+
+var spinFrames = []byte(`|/-\`)
+
+// spinner is the state of the Spinner progress indicator
+type spinner struct {
+	on    bool
+	frame int
+	last  time.Time
+	bytes int64 // Input read so far, for the ETA
+}
+
+// isTerminal reports whether w is a character device such as a TTY
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// spin redraws the Spinner line on stderr if it is due,
+// counting the bytes of the line just read
+func (l *loop) spin(text string) error {
+	if !l.spinner.on {
+		return nil
+	}
+	l.spinner.bytes += int64(len(text)) + 1
+
+	now := l.now()
+	if now.Sub(l.spinner.last) < spinEvery {
+		return nil
+	}
+	l.spinner.last = now
+	l.spinner.frame++
+
+	elapsed := now.Sub(l.start)
+	status := fmt.Sprintf("%c %d lines, %.1f lines/s",
+		spinFrames[l.spinner.frame%len(spinFrames)], l.stats.Lines, float64(l.stats.Lines)/elapsed.Seconds())
+	if total := int64(l.flags.TotalBytes); total > 0 {
+		status += fmt.Sprintf(", ETA %s", eta(l.spinner.bytes, total, elapsed).Round(time.Second))
+	}
+	_, err := fmt.Fprintf(l.stderr, "\r%s\x1b[K", status)
+	return err
+}
+
+// clearSpinner erases the Spinner line once the loop is done
+func (l *loop) clearSpinner() {
+	if l.spinner.on && !l.spinner.last.IsZero() {
+		io.WriteString(l.stderr, "\r\x1b[K")
+	}
+}
+
+// eta estimates the time left to read total bytes, assuming
+// the rest are read as fast as the done bytes took elapsed
+func eta(done, total int64, elapsed time.Duration) time.Duration {
+	if done <= 0 || done >= total {
+		return 0
+	}
+	return time.Duration(float64(elapsed) * float64(total-done) / float64(done))
+}
@@ -0,0 +1,85 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+func TestSpinnerNotTerminal(t *testing.T) {
+	body := func(args ...any) gloo.Command { return nil }
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if isTerminal(file) || isTerminal(&bytes.Buffer{}) {
+		t.Fatal("a file or buffer is reported as a terminal")
+	}
+
+	_, stderr, err := execute(context.Background(), While(body, ShowSpinner), "a\nb\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stderr != "" {
+		t.Errorf("got %q on stderr, want nothing off a terminal", stderr)
+	}
+}
+
+func TestSpinnerETA(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	var stderr bytes.Buffer
+	c := While(nil, ShowSpinner, TotalBytes(20), Clock(clock.Now)).(command)
+	l := c.newLoop(context.Background(), io.Discard, &stderr)
+	l.spinner.on = true
+
+	// A quarter of the input is read in the first second
+	clock.Advance(time.Second)
+	l.stats.Lines = 1
+	if err := l.spin("abcd"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "\r/ 1 lines, 1.0 lines/s, ETA 3s\x1b[K"; stderr.String() != want {
+		t.Errorf("got %q, want %q", stderr.String(), want)
+	}
+
+	// Not redrawn again until spinEvery has passed
+	stderr.Reset()
+	clock.Advance(spinEvery / 2)
+	l.stats.Lines = 2
+	l.spin("efgh")
+	if stderr.Len() != 0 {
+		t.Errorf("redrawn early: %q", stderr.String())
+	}
+
+	stderr.Reset()
+	l.clearSpinner()
+	if want := "\r\x1b[K"; stderr.String() != want {
+		t.Errorf("cleared with %q, want %q", stderr.String(), want)
+	}
+}
+
+func TestETA(t *testing.T) {
+	for _, tt := range []struct {
+		done, total int64
+		elapsed     time.Duration
+		want        time.Duration
+	}{
+		{0, 100, time.Second, 0},
+		{25, 100, time.Second, 3 * time.Second},
+		{50, 100, 10 * time.Second, 10 * time.Second},
+		{100, 100, time.Second, 0},
+		{150, 100, time.Second, 0},
+	} {
+		if got := eta(tt.done, tt.total, tt.elapsed); got != tt.want {
+			t.Errorf("eta(%d, %d, %v) = %v, want %v", tt.done, tt.total, tt.elapsed, got, tt.want)
+		}
+	}
+}
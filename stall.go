@@ -0,0 +1,39 @@
+package command
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// watch starts the StallTimeout watchdog for a line's command,
+// returning a function that stops it once the command returns
+func (l *loop) watch(lineNum int) (stop func()) {
+	if l.flags.StallTimeout <= 0 {
+		return func() {}
+	}
+
+	timeout := time.Duration(l.flags.StallTimeout)
+	timer := time.AfterFunc(timeout, func() {
+		// Written at once so the report is not interleaved with the command's
+		report := fmt.Appendf(nil, "while: line %d stalled for %s\n", lineNum, timeout)
+		if l.flags.StackDumpOnStall {
+			report = append(report, stacks()...)
+		}
+		l.stderr.Write(report)
+	})
+	return func() { timer.Stop() }
+}
+
+// stacks returns the stack traces of every goroutine,
+// growing the buffer until they fit
+func stacks() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
@@ -0,0 +1,36 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+func TestStallDumpsStacks(t *testing.T) {
+	// The command keeps writing to stderr while the watchdog reports it
+	body := func(args ...any) gloo.Command {
+		return gloo.RawCommand(func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+			for i := 0; i < 10; i++ {
+				fmt.Fprintln(stderr, "working")
+				time.Sleep(5 * time.Millisecond)
+			}
+			return nil
+		})
+	}
+
+	_, stderr, err := execute(context.Background(), While(body, StallTimeout(10*time.Millisecond), DumpStacks), "hang\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stderr, "while: line 1 stalled for 10ms\n") {
+		t.Errorf("stderr %q lacks the stall report", stderr)
+	}
+	if !strings.Contains(stderr, "goroutine ") {
+		t.Errorf("stderr %q lacks a stack dump", stderr)
+	}
+}
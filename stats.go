@@ -0,0 +1,14 @@
+package command
+
+// Stats describes a completed run. Pass a *Stats to While to have it filled
+// in when the command returns.
+type Stats struct {
+	Lines       int        // Lines read
+	Executed    int        // Lines the body returned a command for
+	Skipped     int        // Lines the body returned nil for or Skip, StartAt, SkipEmpty, CommentPrefix or a line length flag skipped
+	Concurrency int        // Concurrency limit in effect at the end of a parallel run
+	Suppressed  int        // Outputs dropped by UniqueOutput
+	Checksum    string     // Hex SHA-256 of everything written to stdout, under ChecksumOutput
+	StopReason  StopReason // Why the loop stopped
+	Separator   string     // Field separator chosen by AutoDetectSeparator; "" for whitespace
+}
@@ -0,0 +1,112 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// StopReason tells why a loop stopped
+type StopReason int
+
+const (
+	StopEOF           StopReason = iota // The input ended
+	StopMaxDuration                     // MaxDuration elapsed
+	StopCancelled                       // The context was cancelled or its deadline passed
+	StopError                           // The loop failed
+	StopMaxOutput                       // MaxOutputLines lines were written
+	StopMaxIterations                   // MaxIterations commands were executed
+	StopUntil                           // The Until predicate matched a line
+	StopCondition                       // The WhileCond condition returned false
+	StopBreak                           // A command returned ErrBreak
+	StopAtLine                          // The StopAt predicate matched a line
+)
+
+func (r StopReason) String() string {
+	switch r {
+	case StopEOF:
+		return "eof"
+	case StopMaxDuration:
+		return "max-duration"
+	case StopCancelled:
+		return "cancelled"
+	case StopError:
+		return "error"
+	case StopMaxOutput:
+		return "max-output"
+	case StopMaxIterations:
+		return "max-iterations"
+	case StopUntil:
+		return "until"
+	case StopCondition:
+		return "condition"
+	case StopBreak:
+		return "break"
+	case StopAtLine:
+		return "stop-at"
+	default:
+		return "unknown"
+	}
+}
+
+// errStop ends the loop early without error once a limit is reached
+var errStop = errors.New("while: stopped")
+
+// stop ends the loop for the given reason
+func (l *loop) stop(reason StopReason) error {
+	l.stopReason = reason
+	return errStop
+}
+
+// stopped clears errStop, as stopping at a limit is not a failure
+func stopped(err error) error {
+	if errors.Is(err, errStop) {
+		return nil
+	}
+	return err
+}
+
+// limits stops the loop before the next line once the context is done or
+// a limit has been reached. Together with the check after each command in
+// process, a cancelled loop runs at most the command already started.
+func (l *loop) limits() error {
+	if err := l.ctx.Err(); err != nil {
+		return err
+	}
+	if l.ending {
+		return l.stop(StopAtLine)
+	}
+	if limit := time.Duration(l.flags.MaxDuration); limit > 0 && l.now().Sub(l.start) >= limit {
+		return l.stop(StopMaxDuration)
+	}
+	if l.capped != nil && l.capped.remaining <= 0 {
+		return l.stop(StopMaxOutput)
+	}
+	if limit := int(l.flags.MaxIterations); limit > 0 && l.stats.Executed >= limit {
+		return l.stop(StopMaxIterations)
+	}
+	return nil
+}
+
+// check stops the loop before the next line is read once the WhileCond
+// condition no longer holds
+func (l *loop) check() error {
+	if l.cond != nil && !l.cond() {
+		return l.stop(StopCondition)
+	}
+	return nil
+}
+
+// reason determines why the loop ended with err
+func (l *loop) reason(err error) StopReason {
+	switch {
+	case err == nil && l.stopReason != StopEOF:
+		return l.stopReason
+	case err == nil:
+		return StopEOF
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return StopCancelled
+	default:
+		return StopError
+	}
+}
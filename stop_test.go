@@ -0,0 +1,76 @@
+package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+func TestWhileCondEveryInputPath(t *testing.T) {
+	for name, flag := range map[string]any{
+		"lines":   nil,
+		"csv":     CSVInput,
+		"reverse": Backward,
+		"sorted":  SortedOrder,
+		"detect":  DetectSeparator,
+	} {
+		n := 0
+		cond := func() bool {
+			n++
+			return n <= 2
+		}
+
+		var stats Stats
+		params := []any{&stats}
+		if flag != nil {
+			params = append(params, flag)
+		}
+		out, _, err := execute(context.Background(), WhileCond(cond, quoteFields, params...), "c\nb\na\n")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if strings.Count(out, "\n") != 2 {
+			t.Errorf("%s: got %q, want 2 lines", name, out)
+		}
+		if stats.Lines != 2 || stats.StopReason != StopCondition {
+			t.Errorf("%s: read %d lines, stopped by %s; want 2, condition", name, stats.Lines, stats.StopReason)
+		}
+	}
+}
+
+func TestStopReasonLimits(t *testing.T) {
+	for name, tt := range map[string]struct {
+		perLine  time.Duration
+		limit    MaxIterations
+		executed int
+		reason   StopReason
+	}{
+		"lines first": {time.Millisecond, 5, 5, StopMaxIterations},
+		"time first":  {time.Second, 5, 3, StopMaxDuration},
+		"neither":     {time.Millisecond, 0, 10, StopEOF},
+	} {
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		body := func(args ...any) gloo.Command {
+			clock.Advance(tt.perLine)
+			return echo(args...)
+		}
+		var (
+			stats  Stats
+			onStop StopReason = -1
+		)
+		params := []any{Clock(clock.Now), MaxDuration(3 * time.Second), tt.limit, &stats, OnStop(func(r StopReason) { onStop = r })}
+		_, _, err := execute(context.Background(), While(body, params...), strings.Repeat("x\n", 10))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if stats.Executed != tt.executed {
+			t.Errorf("%s: executed %d, want %d", name, stats.Executed, tt.executed)
+		}
+		if stats.StopReason != tt.reason || onStop != tt.reason {
+			t.Errorf("%s: stopped by %s, OnStop got %s; want %s", name, stats.StopReason, onStop, tt.reason)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package command
+
+import "fmt"
+
+// recordTiming writes the TimingCSVWriter row for a finished line,
+// preceded by the header on the first row
+func (l *loop) recordTiming(r result) error {
+	w := l.flags.TimingCSVWriter.Writer
+	if w == nil {
+		return nil
+	}
+
+	if err := l.timingHeader(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d,%d,%d,%t\n", r.lineNum, len(r.line), r.elapsed.Microseconds(), r.err == nil)
+	return err
+}
+
+// timingHeader writes the TimingCSVWriter header unless it already has been
+func (l *loop) timingHeader() error {
+	w := l.flags.TimingCSVWriter.Writer
+	if w == nil || l.timingStarted {
+		return nil
+	}
+	l.timingStarted = true
+	_, err := fmt.Fprintln(w, "line_number,bytes,duration_us,ok")
+	return err
+}
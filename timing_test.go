@@ -0,0 +1,70 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+func TestTimingCSVWriter(t *testing.T) {
+	body := func(args ...any) gloo.Command {
+		switch args[0] {
+		case "skip":
+			return nil
+		case "bad":
+			return fails(errors.New("failed"))
+		}
+		return echo(args...)
+	}
+
+	for _, n := range []int{1, 4} {
+		var timing bytes.Buffer
+		_, _, err := execute(context.Background(), While(body, TimingCSVWriter{&timing}, KeepGoing, Parallelism(n)), "a\nskip\nbad\nlonger\n")
+		if err == nil {
+			t.Fatal("got nil, want the failure of line 3")
+		}
+
+		rows, err := csv.NewReader(&timing).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 4 {
+			t.Fatalf("Parallelism(%d): got %d rows, want a header and 3 rows", n, len(rows))
+		}
+		want := [][]string{
+			{"line_number", "bytes", "duration_us", "ok"},
+			{"1", "1", "", "true"},
+			{"3", "3", "", "false"},
+			{"4", "6", "", "true"},
+		}
+		for i, row := range rows {
+			if i > 0 {
+				// Durations vary, but must be whole microseconds
+				if _, err := strconv.Atoi(row[2]); err != nil {
+					t.Errorf("Parallelism(%d): row %d duration %q: %v", n, i, row[2], err)
+				}
+				row[2] = ""
+			}
+			if !slices.Equal(row, want[i]) {
+				t.Errorf("Parallelism(%d): row %d is %q, want %q", n, i, row, want[i])
+			}
+		}
+	}
+}
+
+func TestTimingCSVWriterEmpty(t *testing.T) {
+	var timing bytes.Buffer
+	body := func(args ...any) gloo.Command { return nil }
+	if _, _, err := execute(context.Background(), While(body, TimingCSVWriter{&timing}), ""); err != nil {
+		t.Fatal(err)
+	}
+	if want := "line_number,bytes,duration_us,ok\n"; timing.String() != want {
+		t.Errorf("got %q, want only the header", timing.String())
+	}
+}
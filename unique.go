@@ -0,0 +1,27 @@
+package command
+
+// duplicate reports whether line repeats an earlier line under Unique, or
+// the previous line under UniqueAdjacent, remembering it for later lines
+func (l *loop) duplicate(line string) (bool, error) {
+	if l.flags.UniqueAdjacent {
+		repeated := l.hasPrevious && line == l.previous
+		l.previous, l.hasPrevious = line, true
+		if repeated {
+			return true, nil
+		}
+	}
+
+	if l.flags.Unique {
+		if _, ok := l.seen[line]; ok {
+			return true, nil
+		}
+		if err := l.lookbehind(len(l.seen) + 1); err != nil {
+			return false, err
+		}
+		if l.seen == nil {
+			l.seen = make(map[string]struct{})
+		}
+		l.seen[line] = struct{}{}
+	}
+	return false, nil
+}
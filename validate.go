@@ -0,0 +1,71 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrInvalidInput is returned under Validate when any line violates the schema
+var ErrInvalidInput = errors.New("while: invalid input")
+
+// validate reads all of input and reports schema violations to stderr.
+// Lines dropped by Skip, SkipEmpty, CommentPrefix and the line length
+// filters are not checked.
+func (l *loop) validate(input io.Reader) error {
+	var (
+		violations int
+		previous   string
+	)
+
+	report := func(reason string, args ...any) error {
+		violations++
+		_, err := fmt.Fprintf(l.stderr, "while: line %d: %s\n", l.lineNum, fmt.Sprintf(reason, args...))
+		return err
+	}
+
+	err := l.readRecords(input, l.ctx.Err, func(n int, text string) error {
+		if l.skip(n) {
+			return nil
+		}
+		l.lineNum = n
+		line := l.trim(text)
+		if l.ignore(line) {
+			return nil
+		}
+		fields := l.split(line)
+
+		if want := int(l.flags.ExpectFields); want > 0 && len(fields) != want {
+			if err := report("expected %d fields, got %d", want, len(fields)); err != nil {
+				return err
+			}
+		}
+		if l.flags.RequireNonEmpty {
+			for i, field := range fields {
+				if field == "" {
+					if err := report("field %d is empty", i+1); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		if l.flags.Sorted && line < previous {
+			if err := report("out of order"); err != nil {
+				return err
+			}
+		}
+		previous = line
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if violations > 0 {
+		if _, err := fmt.Fprintf(l.stderr, "while: %d violations in %d lines\n", violations, l.lineNum); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %d violations", ErrInvalidInput, violations)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	gloo "github.com/gloo-foo/framework"
+)
+
+func TestValidate(t *testing.T) {
+	called := false
+	body := func(args ...any) gloo.Command {
+		called = true
+		return nil
+	}
+
+	_, stderr, err := execute(context.Background(),
+		While(body, ValidateOnly, FieldSeparator(","), ExpectFields(2), RejectEmpty, AscendingOrder),
+		"a,1\nb,2\nc\nd,\nc,3\ne,4\n")
+	if !errors.Is(err, ErrInvalidInput) || err.Error() != "while: invalid input: 3 violations" {
+		t.Errorf("got %v, want ErrInvalidInput with 3 violations", err)
+	}
+	want := "while: line 3: expected 2 fields, got 1\n" +
+		"while: line 4: field 2 is empty\n" +
+		"while: line 5: out of order\n" +
+		"while: 3 violations in 6 lines\n"
+	if stderr != want {
+		t.Errorf("got stderr %q, want %q", stderr, want)
+	}
+	if called {
+		t.Error("body called under ValidateOnly")
+	}
+}
+
+func TestValidateValid(t *testing.T) {
+	body := func(args ...any) gloo.Command { return nil }
+
+	_, stderr, err := execute(context.Background(), While(body, ValidateOnly, ExpectFields(2), AscendingOrder), "a 1\nb 2\n")
+	if err != nil || stderr != "" {
+		t.Errorf("got %v, stderr %q; want no violations", err, stderr)
+	}
+}
+
+func TestValidateSkipsDroppedLines(t *testing.T) {
+	body := func(args ...any) gloo.Command { return nil }
+
+	_, stderr, err := execute(context.Background(),
+		While(body, ValidateOnly, Skip(1), SkipBlank, CommentPrefix("#"), ExpectFields(2), AscendingOrder),
+		"name value\na 1\n\n# sorted by name\nb 2\nc\n")
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("got %v, want ErrInvalidInput", err)
+	}
+	want := "while: line 6: expected 2 fields, got 1\n" +
+		"while: 1 violations in 6 lines\n"
+	if stderr != want {
+		t.Errorf("got stderr %q, want %q", stderr, want)
+	}
+}